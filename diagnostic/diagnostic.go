@@ -0,0 +1,148 @@
+package diagnostic
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/arjunmayilvaganan/nibbl/token"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+// Code is a stable identifier for a kind of diagnostic, so tools can match
+// on it instead of on the message text.
+type Code string
+
+const (
+	// Lexer
+	IllegalCharacter    Code = "L001"
+	UnterminatedString  Code = "L002"
+	InvalidEscape       Code = "L003"
+	UnterminatedComment Code = "L004"
+	InvalidUTF8         Code = "L005"
+	InvalidCharLiteral  Code = "L006"
+	// Parser
+	UnexpectedToken     Code = "P001"
+	MissingExpression   Code = "P002"
+	InvalidIntegerValue Code = "P003"
+	TooManyErrors       Code = "P004"
+	MissingTerminator   Code = "P005"
+	// P006 retired: integer literals no longer overflow
+	InvalidFloatValue  Code = "P007"
+	FloatOutOfRange    Code = "P008"
+	InvalidAssignment  Code = "P009"
+	NonExhaustiveMatch Code = "P010"
+	// Resolver
+	ConstantAssignment     Code = "R001"
+	ConstantRedeclaration  Code = "R002"
+	LoopControlOutsideLoop Code = "R003"
+)
+
+type Diagnostic struct {
+	Severity Severity
+	Code     Code
+	Message  string
+	Pos      token.Position // start of the offending source
+	End      token.Position // position immediately after the offending source
+	Hints    []string
+}
+
+// String formats the diagnostic as a single line, as returned by the
+// Errors() accessors.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s at %s", d.Message, d.Pos)
+}
+
+// Render formats the diagnostic with the offending line of source and a
+// caret underline beneath the span, followed by any hints.
+//
+//	error[P001]: next token type expected=IDENT, got==
+//	 --> 1:5
+//	  |
+//	1 | let = 5;
+//	  |     ^
+//	  = hint: ...
+func (d Diagnostic) Render(source string) string {
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "%s[%s]: %s\n", d.Severity, d.Code, d.Message)
+
+	gutter := strings.Repeat(" ", len(strconv.Itoa(d.Pos.Line)))
+	fmt.Fprintf(&out, "%s--> %s\n", gutter, d.Pos)
+
+	if line, ok := sourceLine(source, d.Pos); ok {
+		width := 1
+		if d.End.Line == d.Pos.Line && d.End.Column > d.Pos.Column {
+			width = d.End.Column - d.Pos.Column
+		} else if length := utf8.RuneCountInString(line); d.End.Line > d.Pos.Line && length >= d.Pos.Column {
+			width = length - d.Pos.Column + 1
+		}
+
+		fmt.Fprintf(&out, "%s |\n", gutter)
+		fmt.Fprintf(&out, "%d | %s\n", d.Pos.Line, line)
+		fmt.Fprintf(&out, "%s | %s%s\n", gutter, indent(line, d.Pos.Column-1), strings.Repeat("^", width))
+	}
+
+	for _, hint := range d.Hints {
+		fmt.Fprintf(&out, "%s = hint: %s\n", gutter, hint)
+	}
+
+	return out.String()
+}
+
+// sourceLine returns the line of source containing pos, without its
+// line terminator.
+func sourceLine(source string, pos token.Position) (string, bool) {
+	if !pos.IsValid() || pos.Offset > len(source) {
+		return "", false
+	}
+
+	start := strings.LastIndexAny(source[:pos.Offset], "\r\n") + 1
+	end := strings.IndexAny(source[pos.Offset:], "\r\n")
+	if end < 0 {
+		end = len(source)
+	} else {
+		end += pos.Offset
+	}
+
+	return source[start:end], true
+}
+
+// indent returns the whitespace needed to skip the first n runes of line,
+// keeping tabs so the caret stays aligned however tabs are displayed.
+func indent(line string, n int) string {
+	var out bytes.Buffer
+
+	for _, r := range line {
+		if n == 0 {
+			break
+		}
+		if r == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+		n--
+	}
+	out.WriteString(strings.Repeat(" ", n))
+
+	return out.String()
+}
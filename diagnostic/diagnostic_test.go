@@ -0,0 +1,132 @@
+package diagnostic
+
+import (
+	"github.com/arjunmayilvaganan/nibbl/token"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		source     string
+		diagnostic Diagnostic
+		expected   string
+	}{
+		{
+			"let = 5;",
+			Diagnostic{
+				Severity: Error,
+				Code:     UnexpectedToken,
+				Message:  "next token type expected=IDENT, got==",
+				Pos:      token.Position{Offset: 4, Line: 1, Column: 5},
+				End:      token.Position{Offset: 5, Line: 1, Column: 6},
+			},
+			"error[P001]: next token type expected=IDENT, got==\n" +
+				" --> 1:5\n" +
+				"  |\n" +
+				"1 | let = 5;\n" +
+				"  |     ^\n",
+		},
+		{
+			"let x = 1;\r\n\tlet s = \"abc;\n",
+			Diagnostic{
+				Severity: Error,
+				Code:     UnterminatedString,
+				Message:  "unterminated string literal",
+				Pos:      token.Position{Offset: 21, Line: 2, Column: 10},
+				End:      token.Position{Offset: 27, Line: 3, Column: 1},
+				Hints:    []string{"add a closing \""},
+			},
+			"error[L002]: unterminated string literal\n" +
+				" --> 2:10\n" +
+				"  |\n" +
+				"2 | \tlet s = \"abc;\n" +
+				"  | \t        ^^^^^\n" +
+				"  = hint: add a closing \"\n",
+		},
+		{
+			"foo(barbaz)",
+			Diagnostic{
+				Severity: Warning,
+				Code:     MissingExpression,
+				Message:  "something odd",
+				Pos:      token.Position{Offset: 4, Line: 1, Column: 5},
+				End:      token.Position{Offset: 10, Line: 1, Column: 11},
+			},
+			"warning[P002]: something odd\n" +
+				" --> 1:5\n" +
+				"  |\n" +
+				"1 | foo(barbaz)\n" +
+				"  |     ^^^^^^\n",
+		},
+		{
+			"let größe = €;",
+			Diagnostic{
+				Severity: Error,
+				Code:     IllegalCharacter,
+				Message:  "illegal character '€'",
+				Pos:      token.Position{Offset: 14, Line: 1, Column: 13},
+				End:      token.Position{Offset: 17, Line: 1, Column: 14},
+			},
+			"error[L001]: illegal character '€'\n" +
+				" --> 1:13\n" +
+				"  |\n" +
+				"1 | let größe = €;\n" +
+				"  |             ^\n",
+		},
+	}
+
+	for i, tt := range tests {
+		actual := tt.diagnostic.Render(tt.source)
+		if actual != tt.expected {
+			t.Errorf("tests[%d] - expected=\n%s\ngot=\n%s", i, tt.expected, actual)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	d := Diagnostic{
+		Severity: Error,
+		Code:     IllegalCharacter,
+		Message:  "illegal character '@'",
+		Pos:      token.Position{Offset: 0, Line: 1, Column: 1},
+	}
+
+	expected := "illegal character '@' at 1:1"
+	if d.String() != expected {
+		t.Errorf("d.String() expected=%q, got=%q", expected, d.String())
+	}
+}
+
+// Codes are matched on by tools, so a code must never be renumbered or
+// given to a different kind of diagnostic.
+func TestCodesAreStable(t *testing.T) {
+	tests := []struct {
+		code     Code
+		expected string
+	}{
+		{IllegalCharacter, "L001"},
+		{UnterminatedString, "L002"},
+		{InvalidEscape, "L003"},
+		{UnterminatedComment, "L004"},
+		{InvalidUTF8, "L005"},
+		{InvalidCharLiteral, "L006"},
+		{UnexpectedToken, "P001"},
+		{MissingExpression, "P002"},
+		{InvalidIntegerValue, "P003"},
+		{TooManyErrors, "P004"},
+		{MissingTerminator, "P005"},
+		{InvalidFloatValue, "P007"},
+		{FloatOutOfRange, "P008"},
+		{InvalidAssignment, "P009"},
+		{NonExhaustiveMatch, "P010"},
+		{ConstantAssignment, "R001"},
+		{ConstantRedeclaration, "R002"},
+		{LoopControlOutsideLoop, "R003"},
+	}
+
+	for _, tt := range tests {
+		if string(tt.code) != tt.expected {
+			t.Errorf("code expected=%s, got=%s", tt.expected, tt.code)
+		}
+	}
+}
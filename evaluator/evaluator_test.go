@@ -0,0 +1,1084 @@
+package evaluator
+
+import (
+	"github.com/arjunmayilvaganan/nibbl/lexer"
+	"github.com/arjunmayilvaganan/nibbl/object"
+	"github.com/arjunmayilvaganan/nibbl/parser"
+	"math"
+	"testing"
+)
+
+func TestEvalIntegerExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"10", 10},
+		{"-5", -5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"5 * 2 + 10", 20},
+		{"5 + 2 * 10", 25},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+		{"2 * (5 + 10)", 30},
+		{"3 * 3 * 3 + 10", 37},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIntegerOverflowPromotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"-9223372036854775807 - 1 - 1", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"-1 * (-9223372036854775807 - 1)", "9223372036854775808"},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"99999999999999999999 * 99999999999999999999", "9999999999999999999800000000000000000001"},
+		{"0xFFFFFFFFFFFFFFFFF / 2", "147573952589676412927"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		result, ok := evaluated.(*object.BigInteger)
+		if !ok {
+			t.Errorf("object is expected=%s, got=%T (%+v)", "*object.BigInteger", evaluated, evaluated)
+			continue
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("object.Value expected=%s, got=%s", tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestBigIntegerDemotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"9223372036854775807", 9223372036854775807},
+		{"-9223372036854775808", -9223372036854775808},
+		{"-9223372036854775807 - 1", -9223372036854775808},
+		{"9223372036854775808 - 1", 9223372036854775807},
+		{"(9223372036854775807 + 1) / 2", 4611686018427387904},
+		{"99999999999999999999 - 99999999999999999998", 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBigIntegerComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"9223372036854775808 > 9223372036854775807", true},
+		{"1 < 99999999999999999999", true},
+		{"99999999999999999999 == 99999999999999999999", true},
+		{"99999999999999999999 != 99999999999999999998", true},
+		{"99999999999999999999 > 1.5", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.5", 3.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3},
+		{"1 + 0.5", 1.5},
+		{"0.5 + 1", 1.5},
+		{"10 - 2.5", 7.5},
+		{"2 * 1.25", 2.5},
+		{"7 / 2.0", 3.5},
+		{"1e3 * 2", 2000},
+		{"1 / 0.0", math.Inf(1)},
+		{"-1 / 0.0", math.Inf(-1)},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testFloatObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestFloatComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1.5 < 2", true},
+		{"2 > 1.5", true},
+		{"1 == 1.0", true},
+		{"1 != 1.0", false},
+		{"0.1 + 0.2 == 0.3", false},
+		{"let nan = 0.0 / 0.0; nan == nan", false},
+		{"let nan = 0.0 / 0.0; nan != nan", true},
+		{"let nan = 0.0 / 0.0; nan < 1", false},
+		{"let nan = 0.0 / 0.0; nan > 1", false},
+		{"let inf = 1 / 0.0; inf > 1e308", true},
+		{"let inf = 1 / 0.0; inf == inf", true},
+		{"let inf = 1 / 0.0; -inf < inf", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 < 1", false},
+		{"1 > 1", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1 == 2", false},
+		{"1 != 2", true},
+		{"true == true", true},
+		{"false == false", true},
+		{"true == false", false},
+		{"true != false", true},
+		{"false != true", true},
+		{"(1 < 2) == true", true},
+		{"(1 < 2) == false", false},
+		{"(1 > 2) == true", false},
+		{"(1 > 2) == false", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestComparisonAndModuloOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"1.5 <= 1", false},
+		{"99999999999999999999 >= 99999999999999999999", true},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"7 % -3", 1},
+		{"99999999999999999999 % 10", 9},
+		{"5 + 7 % 3 * 2", 7},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		}
+	}
+
+	testFloatObject(t, testEval("7.5 % 2"), 1.5)
+}
+
+func TestBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0b1100 & 0b1010", 0b1000},
+		{"0b1100 | 0b1010", 0b1110},
+		{"0b1100 ^ 0b1010", 0b0110},
+		{"~0", -1},
+		{"~5", -6},
+		{"1 << 10", 1024},
+		{"1024 >> 3", 128},
+		{"-16 >> 2", -4},
+		{"-1 >> 100", -1},
+		{"1 >> 64", 0},
+		{"0xFF00 >> 8 & 0x0F", 0x0F},
+		{"1 | 2 ^ 3 & 4", 3},
+		{"(1 << 64) >> 60", 16},
+		{"~(1 << 70) & 0xFF", 0xFF},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+
+	big := testEval("1 << 64")
+	if big.Inspect() != "18446744073709551616" {
+		t.Errorf("1 << 64 expected=%s, got=%s", "18446744073709551616", big.Inspect())
+	}
+}
+
+func TestExponentiation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"(2 ** 3) ** 2", 64},
+		{"3 ** 0", 1},
+		{"0 ** 0", 1},
+		{"-2 ** 3", -8},
+		{"-1 ** 99999999999999999999", -1},
+		{"2 * 3 ** 2", 18},
+		{"2 ** -1", 0.5},
+		{"2.0 ** 3", 8.0},
+		{"4 ** 0.5", 2.0},
+		{"2 ** 0.5 ** 2", 1.189207115002721},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		}
+	}
+
+	big := testEval("2 ** 100")
+	if big.Inspect() != "1267650600228229401496703205376" {
+		t.Errorf("2 ** 100 expected=%s, got=%s", "1267650600228229401496703205376", big.Inspect())
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false && true", false},
+		{"false || false", false},
+		{"false || true", true},
+		{"true || false", true},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		{"null_value() || 1", true},
+		{"0 && \"\"", true},
+		// The right operand is never evaluated, so the unbound name is fine.
+		{"false && missing", false},
+		{"true || missing", true},
+		{"let calls = fn() { boom }; true || calls()", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval("let null_value = fn() { if (false) { 1 } }; " + tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBangOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"!true", false},
+		{"!false", true},
+		{"!5", false},
+		{"!!true", true},
+		{"!!false", false},
+		{"!!5", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIfElseExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1) { 10 }", 10},
+		{"if (1 < 2) { 10 }", 10},
+		{"if (1 > 2) { 10 }", nil},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (1 > 2) { 10 } else if (2 > 1) { 20 } else { 30 }", 20},
+		{"if (1 > 2) { 10 } else if (2 > 3) { 20 } else { 30 }", 30},
+		{"if (1 > 2) { 10 } else if (2 > 3) { 20 }", nil},
+		{"if (true) {}", nil},
+		{"if (false) { 10 } else {}", nil},
+		{"if (true) { let a = 1 }", nil},
+		{"let x = if (true) {}; x", nil},
+		{"[if (true) {}][0]", nil},
+		{"{1: if (true) {}}[1]", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+
+	testBooleanObject(t, testEval("if (true) {} == 1"), false)
+	testBooleanObject(t, testEval("if (true) {} == if (false) { 1 }"), true)
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"return 2 * 5; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"let a = 3; return a * 2; a;", 6},
+		{
+			`if (10 > 1) {
+				if (10 > 1) {
+					return 10;
+				}
+
+				return 1;
+			}`,
+			10,
+		},
+		{
+			`let f = fn(x) {
+				if (x > 1) {
+					return x;
+				}
+				return 0;
+			};
+			f(5) + f(0);`,
+			5,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a = 10; a;", 10},
+		{"let a = 5; a = 10;", 10},
+		{"let a = 5; a += 3; a;", 8},
+		{"let a = 5; a -= 3; a;", 2},
+		{"let a = 5; a *= 3; a;", 15},
+		{"let a = 15; a /= 3; a;", 5},
+		{"let a = 1; let b = 2; a = b = 7; a + b;", 14},
+		{"let a = 1; let set = fn() { a = 2 }; set(); a;", 2},
+		{"let a = 1; let f = fn() { let a = 5; a = 6; a }; f() + a;", 7},
+		{"let counter = fn() { let n = 0; fn() { n += 1 } }; let c = counter(); c(); c(); c();", 3},
+		{"let arr = [1, 2, 3]; arr[1] = 5; arr[1];", 5},
+		{"let arr = [1, 2, 3]; arr[2] += 10; arr[2];", 13},
+		{"let arr = [1, 2, 3]; let alias = arr; alias[0] = 9; arr[0];", 9},
+		{"let h = {\"a\": 1}; h[\"a\"] *= 4; h[\"a\"];", 4},
+		{"let h = {}; h[\"b\"] = 2; h[\"b\"];", 2},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+
+	result := testEval(`let s = "a"; s += "b"; s;`)
+	if s, ok := result.(*object.String); !ok || s.Value != "ab" {
+		t.Errorf("string += expected=%q, got=%s", "ab", result.Inspect())
+	}
+}
+
+func TestSelfReferencingValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1, 2]; a[0] = a", "[[...], 2]"},
+		{"let a = [1, 2]; a[0] = a; a[0][0][1]", "2"},
+		{`let h = {}; h["s"] = h; h`, "{s: {...}}"},
+		{`let a = [1]; let h = {"a": a}; a[0] = h; a`, "[{a: [...]}]"},
+		{"let x = [1]; [x, x]", "[[1], [1]]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: Inspect expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"const a = 5; a;", 5},
+		{"const a = 5; const b = a * 2; b;", 10},
+		{"const a = 5; let f = fn() { let a = 1; a = 2; a }; f() + a;", 7},
+		{"const a = 5; let f = fn(a) { a += 1; a }; f(1);", 2},
+		{"const arr = [1, 2]; arr[0] = 3; arr[0];", 3},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestWhileLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let i = 0; while (i < 10) { i += 1 }; i", 10},
+		{"let i = 0; while (false) { i += 1 }; i", 0},
+		{"let i = 0; while (true) { i += 1; if (i == 5) { break } }; i", 5},
+		{"let i = 0; let sum = 0; while (i < 10) { i += 1; if (i % 2 == 0) { continue } sum += i }; sum", 25},
+		{"let i = 0; while (i < 3) { let doubled = i * 2; const c = i; i += 1 }; i", 3},
+		{"let f = fn() { let i = 0; while (true) { i += 1; if (i == 4) { return i * 10 } } }; f()", 40},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestForLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let sum = 0; for (let i = 1; i <= 100; i += 1) { sum += i }; sum", 5050},
+		{"let sum = 0; let i = 0; for (i = 0; i < 5; i += 1) { sum += i }; i", 5},
+		{"let n = 0; for (;;) { n += 1; if (n == 7) { break } }; n", 7},
+		{"let sum = 0; for (let i = 0; i < 10; i += 1) { if (i % 3 != 0) { continue } sum += i }; sum", 18},
+		{
+			"let count = 0; for (let i = 0; i < 4; i += 1) { for (let j = 0; j < 4; j += 1) { if (j == i) { break } count += 1 } }; count",
+			6,
+		},
+		{
+			"let count = 0; for (let i = 0; i < 3; i += 1) { let j = 0; while (true) { j += 1; if (j > 2) { break } if (j == 1) { continue } count += 1 } }; count",
+			3,
+		},
+		{"let i = 100; for (let i = 0; i < 3; i += 1) { }; i", 100},
+		{"let find = fn(n) { for (let i = 0; ; i += 1) { if (i * i >= n) { return i } } }; find(50)", 8},
+		{"let f = fn() { for (let i = 0; i < 3; i += 1) { } }; let x = f(); if (x == null_value) { 1 } else { 0 }", 1},
+	}
+
+	for _, tt := range tests {
+		input := "let null_value = if (false) { 1 }; " + tt.input
+		testIntegerObject(t, testEval(input), tt.expected)
+	}
+
+	// Recursion would exhaust the Go stack long before this finishes.
+	testIntegerObject(t, testEval("let n = 0; while (n < 200000) { n += 1 }; n"), 200000)
+}
+
+func TestForInLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let sum = 0; for (x in [1, 2, 3]) { sum += x }; sum", 6},
+		{"let sum = 0; for (i, x in [10, 20, 30]) { sum += i * x }; sum", 80},
+		{"let n = 0; for (x in []) { n += 1 }; n", 0},
+		{"let n = 0; for (c in \"héllo\") { n += 1 }; n", 5},
+		{"let n = 0; for (i, c in \"abc\") { if (c == \"c\") { n = i } }; n", 2},
+		{"let sum = 0; for (v in {\"a\": 1, \"b\": 2}) { sum += v }; sum", 3},
+		{"let sum = 0; for (i in 0..5) { sum += i }; sum", 10},
+		{"let sum = 0; for (i in 1..=5) { sum += i }; sum", 15},
+		{"let n = 0; for (i in 5..0) { n += 1 }; n", 0},
+		{"let sum = 0; for (i, x in 10..13) { sum += i }; sum", 3},
+		{"let n = 0; for (i in 0..1000000000000) { if (i == 3) { break } n += 1 }; n", 3},
+		{"let sum = 0; for (i in 0..10) { if (i % 2 == 0) { continue } sum += i }; sum", 25},
+		{"let x = 100; for (x in 0..3) { }; x", 100},
+		{"let find = fn(xs, y) { for (i, x in xs) { if (x == y) { return i } } }; find([4, 5, 6], 6)", 2},
+		{"let r = 1..4; let sum = 0; for (i in r) { for (j in r) { sum += 1 } }; sum", 9},
+		{"let xs = [1, 2]; let n = 0; for (x in xs) { xs[1] = 5; n += x }; n", 6},
+		{"let h = {1: 1}; let n = 0; for (k in h) { h[k + 1] = 1; n += 1 }; n", 1},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestHashIterationOrder(t *testing.T) {
+	input := `let h = {"c": 3, "a": 1};
+	h["b"] = 2;
+	h["c"] = 30;
+	let keys = "";
+	for (k, v in h) { keys = keys + k };
+	keys`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is expected=%s, got=%T (%+v)", "*object.String", evaluated, evaluated)
+	}
+	if str.Value != "cab" {
+		t.Errorf("keys expected=%q, got=%q", "cab", str.Value)
+	}
+
+	inspected := testEval(`let h = {"c": 3, "a": 1}; h["b"] = 2; h`).Inspect()
+	if inspected != "{c: 3, a: 1, b: 2}" {
+		t.Errorf("Inspect expected=%q, got=%q", "{c: 3, a: 1, b: 2}", inspected)
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	classify := `let classify = fn(v) {
+		match (v) {
+			0 => "zero"
+			-1 => "minus one"
+			1.5 => "one and a half"
+			'a' => "char"
+			"hi" => "greeting"
+			true => "yes"
+			[] => "empty"
+			[x] => "one: " + x
+			[x, y] if x == y => "pair of " + x
+			[_, _] => "pair"
+			{"name": name, "tags": [tag]} => name + "#" + tag
+			{"name": name} => name
+			_ => "other"
+		}
+	};
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"classify(0)", "zero"},
+		{"classify(0.0)", "zero"},
+		{"classify(-1)", "minus one"},
+		{"classify(1.5)", "one and a half"},
+		{"classify(97)", "char"},
+		{"classify(\"hi\")", "greeting"},
+		{"classify(\"0\")", "other"},
+		{"classify(true)", "yes"},
+		{"classify(false)", "other"},
+		{"classify([])", "empty"},
+		{"classify([\"a\"])", "one: a"},
+		{"classify([\"b\", \"b\"])", "pair of b"},
+		{"classify([\"b\", \"c\"])", "pair"},
+		{"classify([1, 2, 3])", "other"},
+		{"classify({\"name\": \"n\", \"tags\": [\"t\"], \"x\": 1})", "n#t"},
+		{"classify({\"name\": \"n\", \"tags\": []})", "n"},
+		{"classify({\"tags\": [\"t\"]})", "other"},
+		{"classify(fn() { 1 })", "other"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(classify + tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%s: object is expected=%s, got=%T (%+v)", tt.input, "*object.String", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, str.Value)
+		}
+	}
+}
+
+func TestMatchScoping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let x = 1; match (5) { x => x }", 5},
+		{"let x = 1; match (5) { x => x }; x", 1},
+		{"let n = 0; match ([1, 2]) { [a, b] if a > b => n = 1, [a, b] => n = a + b }; n", 3},
+		{"let calls = 0; let f = fn() { calls += 1 }; match (f()) { 0 => 0, 2 => 0, _ => 0 }; calls", 1},
+		{"let f = fn(x) { match (x) { 0 => if (true) { return 10 }, _ => 20 }; 30 }; f(0)", 10},
+		{"let f = fn(x) { match (x) { 0 => if (true) { return 10 }, _ => 20 }; 30 }; f(1)", 30},
+		{"let n = 0; for (i in 0..10) { match (i) { 5 => if (true) { break }, _ => n += 1 } }; n", 5},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"5 + true;", "type mismatch: INTEGER + BOOLEAN"},
+		{"5 + true; 5;", "type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"5; true + false; 5", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"let a = -true; 5;", "unknown operator: -BOOLEAN"},
+		{"return true * 2; 5;", "type mismatch: BOOLEAN * INTEGER"},
+		{"10 / (5 - 5)", "division by zero: 10 / 0"},
+		{"99999999999999999999 / 0", "division by zero: 99999999999999999999 / 0"},
+		{"10 % 0", "division by zero: 10 % 0"},
+		{"1 << -1", "negative shift count: 1 << -1"},
+		{"1 >> -2", "negative shift count: 1 >> -2"},
+		{"1 << 99999999", "shift count too large: 1 << 99999999"},
+		{"1.5 & 1", "unknown operator: FLOAT & FLOAT"},
+		{"2 ** 99999999", "exponent too large: 2 ** 99999999"},
+		{"true ** 2", "type mismatch: BOOLEAN ** INTEGER"},
+		{"x = 1", "cannot assign to unbound identifier: x"},
+		{"x += 1", "cannot assign to unbound identifier: x"},
+		{"let f = fn() { y = 1 }; f()", "cannot assign to unbound identifier: y"},
+		{"let a = [1]; a[1] = 2", "index out of range: 1 (length 1)"},
+		{"let a = [1]; a[-1] = 2", "index out of range: -1 (length 1)"},
+		{"let h = {}; h[fn(x) { x }] = 1", "unusable as hash key: FUNCTION"},
+		{"let s = \"abc\"; s[0] = 1", "index assignment not supported: STRING[INTEGER]"},
+		{"let h = {}; h[\"missing\"] += 1", "type mismatch: NULL + INTEGER"},
+		{"let a = 1; a /= 0", "division by zero: 1 / 0"},
+		{"const a = 1; a = 2", "cannot assign to constant: a"},
+		{"const a = 1; a += 2", "cannot assign to constant: a"},
+		{"let f = fn() { a = 2 }; const a = 1; f()", "cannot assign to constant: a"},
+		{"const a = 1; let a = 2", "cannot redeclare constant: a"},
+		{"const a = 1; const a = 2", "cannot redeclare constant: a"},
+		{"break", "break outside of a loop"},
+		{"if (true) { continue }", "continue outside of a loop"},
+		{"let f = fn() { break }; while (true) { f() }", "break outside of a loop"},
+		{"while (missing) { }", "identifier not found: missing"},
+		{"let i = 0; while (true) { i += 1; if (i > 2) { i + true } }", "type mismatch: INTEGER + BOOLEAN"},
+		{"for (let i = 0; i < 3; i += true) { }", "type mismatch: INTEGER + BOOLEAN"},
+		{"for (let i = 0; i < 3; i += 1) { }; i", "identifier not found: i"},
+		{"~true", "unknown operator: ~BOOLEAN"},
+		{"true | false", "unknown operator: BOOLEAN | BOOLEAN"},
+		{"true && missing", "identifier not found: missing"},
+		{"missing || true", "identifier not found: missing"},
+		{"foobar", "identifier not found: foobar"},
+		{`"Hello" - "World"`, "unknown operator: STRING - STRING"},
+		{`"Hello" + 1`, "type mismatch: STRING + INTEGER"},
+		{`[1, 2]["a"]`, "index operator not supported: ARRAY[STRING]"},
+		{`5[0]`, "index operator not supported: INTEGER[INTEGER]"},
+		{`{"name": "Morsl"}[fn(x) { x }];`, "unusable as hash key: FUNCTION"},
+		{`{[1]: 2}`, "unusable as hash key: ARRAY"},
+		{"let x = if (true) {}; x + 1", "type mismatch: NULL + INTEGER"},
+		{"-(if (true) {})", "unknown operator: -NULL"},
+		{"~(if (true) {})", "unknown operator: ~NULL"},
+		{"[if (true) {}][0] + 1", "type mismatch: NULL + INTEGER"},
+		{"for (x in 5) { }", "not iterable: INTEGER"},
+		{"for (x in [1, 2]) { x + true }", "type mismatch: INTEGER + BOOLEAN"},
+		{"for (x in y) { }", "identifier not found: y"},
+		{"1.5..3.0", "unknown operator: FLOAT .. FLOAT"},
+		{"let r = 0..3; r[0]", "index operator not supported: RANGE[INTEGER]"},
+		{"match (5) { 1 => 2 }", "no match arm for value: 5"},
+		{"match (5) { }", "no match arm for value: 5"},
+		{"match (x) { _ => 1 }", "identifier not found: x"},
+		{"match (5) { n if n + true => 1, _ => 2 }", "type mismatch: INTEGER + BOOLEAN"},
+		{"match ({}) { {1.5: x} => x, _ => 2 }", "unusable as hash key: FLOAT"},
+		{"match (1) { 1 => [a], _ => 2 }", "identifier not found: a"},
+		{"if (10 > 1) { true + false; }", "unknown operator: BOOLEAN + BOOLEAN"},
+		{
+			`if (10 > 1) {
+				if (10 > 1) {
+					return true + false;
+				}
+
+				return 1;
+			}`,
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestBooleanSingletons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected object.Object
+	}{
+		{"true", object.TRUE},
+		{"false", object.FALSE},
+		{"1 < 2", object.TRUE},
+		{"!true", object.FALSE},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated != tt.expected {
+			t.Errorf("object is not the shared singleton. expected=%p, got=%p", tt.expected, evaluated)
+		}
+	}
+}
+
+func TestFunctionObject(t *testing.T) {
+	input := "fn(x) { x + 2; };"
+
+	evaluated := testEval(input)
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is expected=%s, got=%T (%+v)", "*object.Function", evaluated, evaluated)
+	}
+
+	if len(fn.Parameters) != 1 {
+		t.Fatalf("Number of parameters expected=%d, got=%d", 1, len(fn.Parameters))
+	}
+
+	if fn.Parameters[0].String() != "x" {
+		t.Fatalf("parameter expected=%q, got=%q", "x", fn.Parameters[0])
+	}
+
+	expectedBody := "{ (x + 2); }"
+	if fn.Body.String() != expectedBody {
+		t.Fatalf("body expected=%q, got=%q", expectedBody, fn.Body.String())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{"let f = fn() { return 1; 2; }; f() + 10;", 11},
+		{"let f = fn() { fn() { return 1; }(); 2; }; f();", 2},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestClosures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let add = fn(x) { fn(y) { x + y } }; let addTwo = add(2); addTwo(3);", 5},
+		{"let add = fn(x) { fn(y) { x + y } }; add(1)(2);", 3},
+		{"let x = 10; let f = fn() { let x = 1; x; }; f() + x;", 11},
+		{"let a = 1; let f = fn(b) { fn(c) { fn(d) { a + b + c + d } } }; f(2)(3)(4);", 10},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"let f = fn(x) { x }; f(1, 2);", "wrong number of arguments: expected=1, got=2"},
+		{"let x = 5; x(1);", "not a function: INTEGER"},
+		{"let f = fn() { y }; f();", "identifier not found: y"},
+		{"let f = fn(x) { fn() { x } }; let g = f(1); x;", "identifier not found: x"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestStringLiteral(t *testing.T) {
+	input := `"Hello\tWorld!\n"`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is expected=%s, got=%T (%+v)", "*object.String", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello\tWorld!\n" {
+		t.Errorf("String.Value expected=%q, got=%q", "Hello\tWorld!\n", str.Value)
+	}
+}
+
+func TestStringInfixExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+		{`let greet = fn(name) { "Hi, " + name }; greet("Morsl")`, "Hi, Morsl"},
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is expected=%s, got=%T (%+v)", "*object.String", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("String.Value expected=%q, got=%q", expected, str.Value)
+			}
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is expected=%s, got=%T (%+v)", "*object.Array", evaluated, evaluated)
+	}
+
+	if len(result.Elements) != 3 {
+		t.Fatalf("Number of elements expected=%d, got=%d", 3, len(result.Elements))
+	}
+
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
+		{"let f = fn() { [4, 5] }; f()[1]", 5},
+		{"[[1, 2], [3, 4]][1][0]", 3},
+		{"[1, 2, 3][3]", nil},
+		{"[1, 2, 3][-1]", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("object is expected=%s, got=%T (%+v)", "*object.Hash", evaluated, evaluated)
+	}
+
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		object.TRUE.HashKey():                      5,
+		object.FALSE.HashKey():                     6,
+	}
+
+	if result.Len() != len(expected) {
+		t.Fatalf("Number of pairs expected=%d, got=%d", len(expected), result.Len())
+	}
+
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Get(expectedKey)
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+			continue
+		}
+
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+		{`{1: 5}[true]`, nil},
+		{`let h = {"a": {"b": 7}}; h["a"]["b"]`, 7},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func testEval(input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+
+	return Eval(program, env)
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is expected=%s, got=%T (%+v)", "*object.Integer", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object.Value expected=%d, got=%d", expected, result.Value)
+		return false
+	}
+
+	return true
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is expected=%s, got=%T (%+v)", "*object.Float", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object.Value expected=%g, got=%g", expected, result.Value)
+		return false
+	}
+
+	return true
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("object is expected=%s, got=%T (%+v)", "*object.Boolean", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object.Value expected=%t, got=%t", expected, result.Value)
+		return false
+	}
+
+	return true
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != object.NULL {
+		t.Errorf("object is expected=NULL, got=%T (%+v)", obj, obj)
+		return false
+	}
+	return true
+}
@@ -1,78 +1,397 @@
 package lexer
 
 import (
+	"fmt"
+	"github.com/arjunmayilvaganan/nibbl/diagnostic"
 	"github.com/arjunmayilvaganan/nibbl/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// Lexer turns UTF-8 source text into tokens. Positions are byte offsets,
+// while columns count runes.
 type Lexer struct {
 	input        string
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
-	ch           byte // current char under examination
+	ch           rune // current char under examination
+	line         int  // line of the current char, starting at 1
+	column       int  // column of the current char, starting at 1
+
+	keepComments bool
+
+	diagnostics []diagnostic.Diagnostic
+}
+
+type Option func(*Lexer)
+
+// KeepComments makes the lexer return comments as token.COMMENT tokens
+// instead of skipping them, for tools such as formatters.
+func KeepComments() Option {
+	return func(l *Lexer) { l.keepComments = true }
 }
 
-func New(input string) *Lexer {
-	l := &Lexer{input: input}
+func New(input string, opts ...Option) *Lexer {
+	l := &Lexer{input: input, line: 1}
+	for _, opt := range opts {
+		opt(l)
+	}
 	l.readChar()
 	return l
 }
 
-func (l *Lexer) skipWhitespace() {
+// Diagnostics returns the lexical errors found so far. Tokens that caused
+// an error are emitted as token.ILLEGAL.
+func (l *Lexer) Diagnostics() []diagnostic.Diagnostic {
+	return l.diagnostics
+}
+
+func (l *Lexer) Errors() []string {
+	errors := []string{}
+	for _, d := range l.diagnostics {
+		errors = append(errors, d.String())
+	}
+	return errors
+}
+
+func (l *Lexer) error(code diagnostic.Code, start, end token.Position, format string, a ...interface{}) {
+	l.diagnostics = append(l.diagnostics, diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Pos:      start,
+		End:      end,
+	})
+}
+
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{Offset: l.position, Line: l.line, Column: l.column}
+}
+
+// nextPosition returns the position immediately after the current char.
+func (l *Lexer) nextPosition() token.Position {
+	return token.Position{Offset: l.readPosition, Line: l.line, Column: l.column + 1}
+}
+
+// skipWhitespace advances past whitespace and reports whether it
+// contained a line break.
+func (l *Lexer) skipWhitespace() bool {
+	newline := false
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+		if l.ch == '\n' || l.ch == '\r' {
+			newline = true
+		}
 		l.readChar()
 	}
+	return newline
+}
+
+func (l *Lexer) atComment() bool {
+	return l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*')
+}
+
+// readComment consumes a // line comment or a /* block comment */ starting
+// at the current char and returns its text. Block comments nest. The
+// lexer is left on the char following the comment.
+func (l *Lexer) readComment() (string, bool) {
+	start := l.currentPosition()
+
+	if l.peekChar() == '/' {
+		for l.ch != '\n' && l.ch != '\r' && l.ch != 0 {
+			l.readChar()
+		}
+		return l.input[start.Offset:l.position], true
+	}
+
+	l.readChar()
+	l.readChar()
+
+	depth := 1
+	for depth > 0 {
+		switch {
+		case l.ch == 0 && l.position >= len(l.input):
+			l.error(diagnostic.UnterminatedComment, start, l.currentPosition(), "unterminated block comment")
+			return l.input[start.Offset:], false
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
+		l.readChar()
+	}
+
+	return l.input[start.Offset:l.position], true
 }
 
 func (l *Lexer) readChar() {
+	// Already at the end of input; stay there so every EOF token shares
+	// the same position.
+	if l.readPosition > len(l.input) {
+		return
+	}
+
+	// A line ends at \n, or at a \r that is not the first half of \r\n.
+	if l.ch == '\n' || l.ch == '\r' && l.peekChar() != '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.column += 1
+
+	width := 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 	}
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += width
+
+	if l.invalidChar() {
+		l.error(diagnostic.InvalidUTF8, l.currentPosition(), l.nextPosition(),
+			"invalid UTF-8 encoding %q", l.input[l.position:l.readPosition])
+	}
+}
+
+// invalidChar reports whether the current char is a byte that is not
+// valid UTF-8, as opposed to an encoded U+FFFD.
+func (l *Lexer) invalidChar() bool {
+	return l.ch == utf8.RuneError && l.readPosition-l.position == 1
 }
 
-func (l *Lexer) peekChar() byte {
+func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	} else {
-		return l.input[l.readPosition]
+		r, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+		return r
 	}
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// Identifiers start with a Unicode letter (category L) or underscore,
+// followed by any number of letters, underscores and Unicode decimal
+// digits (category Nd).
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+func isIdentifierChar(ch rune) bool {
+	return isLetter(ch) || unicode.IsDigit(ch)
 }
 
-func isDigit(ch byte) bool {
+// isDigit only accepts ASCII digits; other decimal digits may appear in
+// identifiers but not in number literals.
+func isDigit(ch rune) bool {
 	return ch >= '0' && ch <= '9'
 }
 
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isIdentifierChar(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber consumes a number literal and reports whether it is an
+// integer or a float. Integers may carry a base prefix (0x, 0o, 0b) and
+// _ separators; floats have a fraction, an exponent or both. Trailing
+// letters are kept in the literal so the parser can report malformed
+// numbers like 12ab as a whole.
+func (l *Lexer) readNumber() (token.TokenType, string) {
 	position := l.position
-	for isDigit(l.ch) {
+	tokenType := token.TokenType(token.INT)
+
+	if l.ch == '0' && strings.ContainsRune("xXoObB", l.peekChar()) {
+		l.readAlphanumeric()
+		return tokenType, l.input[position:l.position]
+	}
+
+	l.readDigits()
+
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
 		l.readChar()
+		l.readDigits()
 	}
-	return l.input[position:l.position]
+
+	if (l.ch == 'e' || l.ch == 'E') && l.atExponent() {
+		tokenType = token.FLOAT
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		l.readDigits()
+	}
+
+	l.readAlphanumeric()
+	return tokenType, l.input[position:l.position]
+}
+
+// atExponent reports whether the e or E under the cursor starts an
+// exponent, i.e. is followed by digits with an optional sign.
+func (l *Lexer) atExponent() bool {
+	rest := l.input[l.readPosition:]
+	if len(rest) > 0 && (rest[0] == '+' || rest[0] == '-') {
+		rest = rest[1:]
+	}
+	return len(rest) > 0 && isDigit(rune(rest[0]))
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+}
+
+func (l *Lexer) readAlphanumeric() {
+	for isDigit(l.ch) || 'a' <= l.ch && l.ch <= 'z' || 'A' <= l.ch && l.ch <= 'Z' || l.ch == '_' {
+		l.readChar()
+	}
+}
+
+// readString consumes a double-quoted string starting at the opening quote
+// and returns its decoded value. The lexer is left on the closing quote.
+func (l *Lexer) readString() (string, bool) {
+	return l.readQuoted('"', "string")
+}
+
+// readQuoted consumes text delimited by quote, decoding escape sequences.
+func (l *Lexer) readQuoted(quote rune, kind string) (string, bool) {
+	start := l.currentPosition()
+	var out strings.Builder
+
+	for {
+		l.readChar()
+
+		switch {
+		case l.ch == quote:
+			return out.String(), true
+		case l.ch == 0 && l.position >= len(l.input):
+			l.error(diagnostic.UnterminatedString, start, l.currentPosition(), "unterminated %s literal", kind)
+			return l.input[start.Offset:], false
+		case l.ch == '\\':
+			l.readEscape(&out)
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+func (l *Lexer) readEscape(out *strings.Builder) {
+	start := l.currentPosition()
+	l.readChar()
+
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case '"':
+		out.WriteByte('"')
+	case '\'':
+		out.WriteByte('\'')
+	case '\\':
+		out.WriteByte('\\')
+	case 'u':
+		if l.peekChar() != '{' {
+			l.error(diagnostic.InvalidEscape, start, l.nextPosition(), "malformed unicode escape, expected \\u{...}")
+			return
+		}
+		l.readChar()
+
+		digitsStart := l.readPosition
+		for isHexDigit(l.peekChar()) {
+			l.readChar()
+		}
+		digits := l.input[digitsStart:l.readPosition]
+
+		if l.peekChar() != '}' || len(digits) == 0 || len(digits) > 6 {
+			l.error(diagnostic.InvalidEscape, start, l.nextPosition(), "malformed unicode escape, expected \\u{...}")
+			return
+		}
+		l.readChar()
+
+		code, _ := strconv.ParseUint(digits, 16, 32)
+		if !utf8.ValidRune(rune(code)) {
+			l.error(diagnostic.InvalidEscape, start, l.nextPosition(), "invalid unicode code point %s", digits)
+			return
+		}
+		out.WriteRune(rune(code))
+	case 0:
+		// End of input; readQuoted reports the unterminated literal.
+	default:
+		l.error(diagnostic.InvalidEscape, start, l.nextPosition(), "unknown escape sequence \\%c", l.ch)
+	}
+}
+
+// readCharLiteral consumes a single-quoted character literal such as 'a'
+// or '\n' and returns its spelling, quotes included.
+func (l *Lexer) readCharLiteral() (string, bool) {
+	start := l.currentPosition()
+
+	value, ok := l.readQuoted('\'', "character")
+	if !ok {
+		return value, false
+	}
+
+	if utf8.RuneCountInString(value) != 1 {
+		l.error(diagnostic.InvalidCharLiteral, start, l.nextPosition(),
+			"character literal must contain exactly one character")
+		return l.input[start.Offset:l.readPosition], false
+	}
+
+	return l.input[start.Offset:l.readPosition], true
+}
+
+// CharValue returns the code point of a character literal spelling, as
+// produced for token.CHAR.
+func CharValue(literal string) rune {
+	l := New(literal)
+	value, _ := l.readQuoted('\'', "character")
+	r, _ := utf8.DecodeRuneInString(value)
+	return r
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
+	newline := l.skipWhitespace()
 
-	l.skipWhitespace()
+	for !l.keepComments && l.atComment() {
+		comment, _ := l.readComment()
+		if strings.ContainsAny(comment, "\r\n") {
+			newline = true
+		}
+		if l.skipWhitespace() {
+			newline = true
+		}
+	}
+
+	start := l.currentPosition()
+	tok := l.readToken()
+	tok.Pos = start
+	tok.End = l.currentPosition()
+	tok.NewlineBefore = newline
+
+	if tok.Type == token.EOF {
+		tok.End = start
+	}
+
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
 
 	switch l.ch {
 	case '=':
@@ -80,11 +399,17 @@ func (l *Lexer) NextToken() token.Token {
 			ch := l.ch
 			l.readChar()
 			tok = token.Token{Type: token.EQ, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: string(ch) + string(l.ch)}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -93,12 +418,28 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
+	case '[':
+		tok = newToken(token.LBRACKET, l.ch)
+	case ']':
+		tok = newToken(token.RBRACKET, l.ch)
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.PLUS_ASSIGN, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.MINUS_ASSIGN, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -108,13 +449,104 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		if l.atComment() {
+			comment, ok := l.readComment()
+			if !ok {
+				return token.Token{Type: token.ILLEGAL, Literal: comment}
+			}
+			return token.Token{Type: token.COMMENT, Literal: comment}
+		}
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.SLASH_ASSIGN, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.SLASH, l.ch)
+		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '*' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.POWER, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.ASTERISK_ASSIGN, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.ASTERISK, l.ch)
+		}
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.LT_EQ, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '<' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.SHL, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.GT_EQ, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.SHR, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
+	case '&':
+		if l.peekChar() == '&' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.AND, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.BIT_AND, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.OR, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.BIT_OR, l.ch)
+		}
+	case '^':
+		tok = newToken(token.BIT_XOR, l.ch)
+	case '.':
+		if l.peekChar() == '.' {
+			l.readChar()
+			if l.peekChar() == '=' {
+				l.readChar()
+				tok = token.Token{Type: token.DOTDOT_EQ, Literal: "..="}
+			} else {
+				tok = token.Token{Type: token.DOTDOT, Literal: ".."}
+			}
+		} else {
+			l.error(diagnostic.IllegalCharacter, l.currentPosition(), l.nextPosition(), "illegal character %q", l.ch)
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '~':
+		tok = newToken(token.BIT_NOT, l.ch)
+	case '\'':
+		literal, ok := l.readCharLiteral()
+		if !ok {
+			l.readChar()
+			return token.Token{Type: token.ILLEGAL, Literal: literal}
+		}
+		tok = token.Token{Type: token.CHAR, Literal: literal}
+	case '"':
+		literal, ok := l.readString()
+		if !ok {
+			return token.Token{Type: token.ILLEGAL, Literal: literal}
+		}
+		tok = token.Token{Type: token.STRING, Literal: literal}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -124,10 +556,13 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
 			return tok
+		} else if l.invalidChar() {
+			// Already reported by readChar.
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.input[l.position:l.readPosition]}
 		} else {
+			l.error(diagnostic.IllegalCharacter, l.currentPosition(), l.nextPosition(), "illegal character %q", l.ch)
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
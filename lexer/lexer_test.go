@@ -14,7 +14,7 @@ let add = fn(x, y) {
 };
 
 let result = add(five, ten);
-!-/*5;
+!-/ *5;
 5 < 10 > 5;
 
 if(5 < 10) {
@@ -25,6 +25,18 @@ if(5 < 10) {
 
 10 == 10;
 10 != 9;
+"foobar"
+"foo bar"
+[1, 2];
+{"foo": "bar"}
+1 <= 2 >= 3 % 4 && a || b;
+~a & b | c ^ d << 1 >> 2;
+2 ** 3 * 4;
+x = 1; x += 2; x -= 3; x *= 4; x /= 5;
+const PI = 3;
+while for break continue
+for (x in 0..10) 1..=5 .
+match (x) { _ => _y, == => }
 `
 
 	tests := []struct {
@@ -104,6 +116,103 @@ if(5 < 10) {
 		{token.NOT_EQ, "!="},
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
+		{token.STRING, "foobar"},
+		{token.STRING, "foo bar"},
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RBRACKET, "]"},
+		{token.SEMICOLON, ";"},
+		{token.LBRACE, "{"},
+		{token.STRING, "foo"},
+		{token.COLON, ":"},
+		{token.STRING, "bar"},
+		{token.RBRACE, "}"},
+		{token.INT, "1"},
+		{token.LT_EQ, "<="},
+		{token.INT, "2"},
+		{token.GT_EQ, ">="},
+		{token.INT, "3"},
+		{token.PERCENT, "%"},
+		{token.INT, "4"},
+		{token.AND, "&&"},
+		{token.IDENT, "a"},
+		{token.OR, "||"},
+		{token.IDENT, "b"},
+		{token.SEMICOLON, ";"},
+		{token.BIT_NOT, "~"},
+		{token.IDENT, "a"},
+		{token.BIT_AND, "&"},
+		{token.IDENT, "b"},
+		{token.BIT_OR, "|"},
+		{token.IDENT, "c"},
+		{token.BIT_XOR, "^"},
+		{token.IDENT, "d"},
+		{token.SHL, "<<"},
+		{token.INT, "1"},
+		{token.SHR, ">>"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.INT, "2"},
+		{token.POWER, "**"},
+		{token.INT, "3"},
+		{token.ASTERISK, "*"},
+		{token.INT, "4"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.INT, "4"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.CONST, "const"},
+		{token.IDENT, "PI"},
+		{token.ASSIGN, "="},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.WHILE, "while"},
+		{token.FOR, "for"},
+		{token.BREAK, "break"},
+		{token.CONTINUE, "continue"},
+		{token.FOR, "for"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.IN, "in"},
+		{token.INT, "0"},
+		{token.DOTDOT, ".."},
+		{token.INT, "10"},
+		{token.RPAREN, ")"},
+		{token.INT, "1"},
+		{token.DOTDOT_EQ, "..="},
+		{token.INT, "5"},
+		{token.ILLEGAL, "."},
+		{token.MATCH, "match"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.WILDCARD, "_"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "_y"},
+		{token.COMMA, ","},
+		{token.EQ, "=="},
+		{token.ARROW, "=>"},
+		{token.RBRACE, "}"},
 		{token.EOF, ""},
 	}
 
@@ -122,3 +231,498 @@ if(5 < 10) {
 		}
 	}
 }
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a\nb"`, "a\nb"},
+		{`"a\tb"`, "a\tb"},
+		{`"a\"b"`, `a"b`},
+		{`"a\\b"`, `a\b`},
+		{`"\u{e9}t\u{E9}"`, "été"},
+		{`"\u{1F600}"`, "\U0001F600"},
+		{`"multi
+line"`, "multi\nline"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != token.STRING {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, token.STRING, tok.Type)
+		}
+		if tok.Literal != tt.expected {
+			t.Errorf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expected, tok.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("tests[%d] - unexpected errors: %v", i, l.Errors())
+		}
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedType  token.TokenType
+		expectedError string
+	}{
+		{`"abc`, token.ILLEGAL, "unterminated string literal at 1:1"},
+		{`x = "abc\`, token.ILLEGAL, "unterminated string literal at 1:5"},
+		{`"\q"`, token.STRING, "unknown escape sequence \\q at 1:2"},
+		{`"\u0041"`, token.STRING, "malformed unicode escape, expected \\u{...} at 1:2"},
+		{`"\u{110000}"`, token.STRING, "invalid unicode code point 110000 at 1:2"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+
+		var tok token.Token
+		for tok = l.NextToken(); tok.Type != token.STRING && tok.Type != token.ILLEGAL; tok = l.NextToken() {
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Errorf("tests[%d] - expected EOF after string, got=%q", i, next.Type)
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - number of errors expected=%d, got=%d (%v)", i, 1, len(errors), errors)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("tests[%d] - error expected=%q, got=%q", i, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestNumberAndCharLiterals(t *testing.T) {
+	input := `0xFF 0o17 0b1010 1_000_000 'a' '\n' '\'' 'é'`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "0xFF"},
+		{token.INT, "0o17"},
+		{token.INT, "0b1010"},
+		{token.INT, "1_000_000"},
+		{token.CHAR, "'a'"},
+		{token.CHAR, `'\n'`},
+		{token.CHAR, `'\''`},
+		{token.CHAR, "'é'"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestFloatLiterals(t *testing.T) {
+	input := `3.14 1e-9 2E10 6.02e+23 1_000.5 1..2 0x1e-5 1.foo`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "1e-9"},
+		{token.FLOAT, "2E10"},
+		{token.FLOAT, "6.02e+23"},
+		{token.FLOAT, "1_000.5"},
+		{token.INT, "1"},
+		{token.DOTDOT, ".."},
+		{token.INT, "2"},
+		{token.INT, "0x1e"},
+		{token.MINUS, "-"},
+		{token.INT, "5"},
+		{token.INT, "1"},
+		{token.ILLEGAL, "."},
+		{token.IDENT, "foo"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestCharValue(t *testing.T) {
+	tests := []struct {
+		literal  string
+		expected rune
+	}{
+		{"'a'", 'a'},
+		{`'\n'`, '\n'},
+		{`'\''`, '\''},
+		{`'"'`, '"'},
+		{"'é'", 'é'},
+		{`'\u{1F600}'`, '\U0001F600'},
+	}
+
+	for _, tt := range tests {
+		if got := CharValue(tt.literal); got != tt.expected {
+			t.Errorf("CharValue(%s) expected=%q, got=%q", tt.literal, tt.expected, got)
+		}
+	}
+}
+
+func TestCharLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`'a`, "unterminated character literal at 1:1"},
+		{`''`, "character literal must contain exactly one character at 1:1"},
+		{`'ab'`, "character literal must contain exactly one character at 1:1"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+
+		if tok := l.NextToken(); tok.Type != token.ILLEGAL {
+			t.Errorf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, token.ILLEGAL, tok.Type)
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Errorf("tests[%d] - expected EOF after literal, got=%q", i, next.Type)
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - number of errors expected=%d, got=%d (%v)", i, 1, len(errors), errors)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("tests[%d] - error expected=%q, got=%q", i, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5;\nx + 10;\r\n\"hi\"\r[1]"
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Position
+		expectedEnd  token.Position
+	}{
+		{token.LET, token.Position{Offset: 0, Line: 1, Column: 1}, token.Position{Offset: 3, Line: 1, Column: 4}},
+		{token.IDENT, token.Position{Offset: 4, Line: 1, Column: 5}, token.Position{Offset: 5, Line: 1, Column: 6}},
+		{token.ASSIGN, token.Position{Offset: 6, Line: 1, Column: 7}, token.Position{Offset: 7, Line: 1, Column: 8}},
+		{token.INT, token.Position{Offset: 8, Line: 1, Column: 9}, token.Position{Offset: 9, Line: 1, Column: 10}},
+		{token.SEMICOLON, token.Position{Offset: 9, Line: 1, Column: 10}, token.Position{Offset: 10, Line: 1, Column: 11}},
+		{token.IDENT, token.Position{Offset: 11, Line: 2, Column: 1}, token.Position{Offset: 12, Line: 2, Column: 2}},
+		{token.PLUS, token.Position{Offset: 13, Line: 2, Column: 3}, token.Position{Offset: 14, Line: 2, Column: 4}},
+		{token.INT, token.Position{Offset: 15, Line: 2, Column: 5}, token.Position{Offset: 17, Line: 2, Column: 7}},
+		{token.SEMICOLON, token.Position{Offset: 17, Line: 2, Column: 7}, token.Position{Offset: 18, Line: 2, Column: 8}},
+		{token.STRING, token.Position{Offset: 20, Line: 3, Column: 1}, token.Position{Offset: 24, Line: 3, Column: 5}},
+		{token.LBRACKET, token.Position{Offset: 25, Line: 4, Column: 1}, token.Position{Offset: 26, Line: 4, Column: 2}},
+		{token.INT, token.Position{Offset: 26, Line: 4, Column: 2}, token.Position{Offset: 27, Line: 4, Column: 3}},
+		{token.RBRACKET, token.Position{Offset: 27, Line: 4, Column: 3}, token.Position{Offset: 28, Line: 4, Column: 4}},
+		{token.EOF, token.Position{Offset: 28, Line: 4, Column: 4}, token.Position{Offset: 28, Line: 4, Column: 4}},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Pos != tt.expectedPos {
+			t.Errorf("tests[%d] - pos wrong. expected=%+v, got=%+v", i, tt.expectedPos, tok.Pos)
+		}
+		if tok.End != tt.expectedEnd {
+			t.Errorf("tests[%d] - end wrong. expected=%+v, got=%+v", i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestRepeatedEOF(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedPos token.Position
+	}{
+		{"a", token.Position{Offset: 1, Line: 1, Column: 2}},
+		{"", token.Position{Offset: 0, Line: 1, Column: 1}},
+		{"a\n", token.Position{Offset: 2, Line: 2, Column: 1}},
+		{"\"open", token.Position{Offset: 5, Line: 1, Column: 6}},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		for i := 0; i < 3; i++ {
+			tok := l.NextToken()
+			if tok.Type != token.EOF {
+				t.Fatalf("%q: token %d after EOF expected=%q, got=%q", tt.input, i, token.EOF, tok.Type)
+			}
+			if tok.Pos != tt.expectedPos || tok.End != tt.expectedPos {
+				t.Errorf("%q: EOF %d span expected=%+v, got=%+v-%+v", tt.input, i, tt.expectedPos, tok.Pos, tok.End)
+			}
+		}
+	}
+}
+
+func TestNewlineBefore(t *testing.T) {
+	input := "let x = 1\nlet y = 2;\r\nx\r\r+ y"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedNewline bool
+	}{
+		{token.LET, false},
+		{token.IDENT, false},
+		{token.ASSIGN, false},
+		{token.INT, false},
+		{token.LET, true},
+		{token.IDENT, false},
+		{token.ASSIGN, false},
+		{token.INT, false},
+		{token.SEMICOLON, false},
+		{token.IDENT, true},
+		{token.PLUS, true},
+		{token.IDENT, false},
+		{token.EOF, false},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.NewlineBefore != tt.expectedNewline {
+			t.Errorf("tests[%d] - NewlineBefore wrong. expected=%t, got=%t", i, tt.expectedNewline, tok.NewlineBefore)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+let x = 5; // trailing
+/* block */ let y = /* inline */ 10;
+/* outer /* nested */ still outer */
+x / y;
+/* spans
+lines */ z`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedNewline bool
+	}{
+		{token.LET, "let", true},
+		{token.IDENT, "x", false},
+		{token.ASSIGN, "=", false},
+		{token.INT, "5", false},
+		{token.SEMICOLON, ";", false},
+		{token.LET, "let", true},
+		{token.IDENT, "y", false},
+		{token.ASSIGN, "=", false},
+		{token.INT, "10", false},
+		{token.SEMICOLON, ";", false},
+		{token.IDENT, "x", true},
+		{token.SLASH, "/", false},
+		{token.IDENT, "y", false},
+		{token.SEMICOLON, ";", false},
+		{token.IDENT, "z", true},
+		{token.EOF, "", false},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.NewlineBefore != tt.expectedNewline {
+			t.Errorf("tests[%d] - NewlineBefore wrong. expected=%t, got=%t", i, tt.expectedNewline, tok.NewlineBefore)
+		}
+	}
+}
+
+func TestKeepComments(t *testing.T) {
+	input := `let x = 5; // trailing
+/* a /* b */ c */ x`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.COMMENT, "// trailing"},
+		{token.COMMENT, "/* a /* b */ c */"},
+		{token.IDENT, "x"},
+		{token.EOF, ""},
+	}
+
+	l := New(input, KeepComments())
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	for _, opts := range [][]Option{nil, {KeepComments()}} {
+		l := New("x /* a /* b */", opts...)
+
+		l.NextToken()
+		tok := l.NextToken()
+		if opts != nil && tok.Type != token.ILLEGAL {
+			t.Errorf("tokentype wrong. expected=%q, got=%q", token.ILLEGAL, tok.Type)
+		}
+		if opts == nil && tok.Type != token.EOF {
+			t.Errorf("tokentype wrong. expected=%q, got=%q", token.EOF, tok.Type)
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 || errors[0] != "unterminated block comment at 1:3" {
+			t.Errorf("unexpected errors: %q", errors)
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := "let größe = 変数 + x1 + _a٣;\n\"héllo\" ñ"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedPos     token.Position
+	}{
+		{token.LET, "let", token.Position{Offset: 0, Line: 1, Column: 1}},
+		{token.IDENT, "größe", token.Position{Offset: 4, Line: 1, Column: 5}},
+		{token.ASSIGN, "=", token.Position{Offset: 12, Line: 1, Column: 11}},
+		{token.IDENT, "変数", token.Position{Offset: 14, Line: 1, Column: 13}},
+		{token.PLUS, "+", token.Position{Offset: 21, Line: 1, Column: 16}},
+		{token.IDENT, "x1", token.Position{Offset: 23, Line: 1, Column: 18}},
+		{token.PLUS, "+", token.Position{Offset: 26, Line: 1, Column: 21}},
+		{token.IDENT, "_a٣", token.Position{Offset: 28, Line: 1, Column: 23}},
+		{token.SEMICOLON, ";", token.Position{Offset: 32, Line: 1, Column: 26}},
+		{token.STRING, "héllo", token.Position{Offset: 34, Line: 2, Column: 1}},
+		{token.IDENT, "ñ", token.Position{Offset: 43, Line: 2, Column: 9}},
+		{token.EOF, "", token.Position{Offset: 45, Line: 2, Column: 10}},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos != tt.expectedPos {
+			t.Errorf("tests[%d] - pos wrong. expected=%+v, got=%+v", i, tt.expectedPos, tok.Pos)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %q", l.Errors())
+	}
+}
+
+func TestNonIdentifierRunes(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"٣x", "illegal character '٣' at 1:1"},
+		{"a → b", "illegal character '→' at 1:3"},
+		{"€", "illegal character '€' at 1:1"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			t.Errorf("tests[%d] - errors expected=[%q], got=%q", i, tt.expectedError, errors)
+		}
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedTypes []token.TokenType
+		expectedError string
+	}{
+		{"x \xff y", []token.TokenType{token.IDENT, token.ILLEGAL, token.IDENT}, `invalid UTF-8 encoding "\xff" at 1:3`},
+		{"é\xc3", []token.TokenType{token.IDENT, token.ILLEGAL}, `invalid UTF-8 encoding "\xc3" at 1:2`},
+		{"\"a\xffb\"", []token.TokenType{token.STRING}, `invalid UTF-8 encoding "\xff" at 1:3`},
+		{"\"�\"", []token.TokenType{token.STRING}, ""},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+
+		for j, expectedType := range tt.expectedTypes {
+			tok := l.NextToken()
+			if tok.Type != expectedType {
+				t.Errorf("tests[%d] - token %d type wrong. expected=%q, got=%q", i, j, expectedType, tok.Type)
+			}
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Errorf("tests[%d] - expected EOF, got=%q", i, tok.Type)
+		}
+
+		errors := l.Errors()
+		if tt.expectedError == "" {
+			if len(errors) != 0 {
+				t.Errorf("tests[%d] - unexpected errors: %q", i, errors)
+			}
+			continue
+		}
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			t.Errorf("tests[%d] - errors expected=[%q], got=%q", i, tt.expectedError, errors)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package object
+
+type Environment struct {
+	store     map[string]Object
+	constants map[string]bool // names in store declared with const
+	outer     *Environment
+}
+
+func NewEnvironment() *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s, constants: make(map[string]bool), outer: nil}
+}
+
+// NewEnclosedEnvironment creates a scope whose lookups fall back to outer,
+// as used for function bodies and closures.
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		obj, ok = e.outer.Get(name)
+	}
+	return obj, ok
+}
+
+func (e *Environment) Set(name string, val Object) Object {
+	e.store[name] = val
+	delete(e.constants, name)
+	return val
+}
+
+// SetConst binds name like Set and marks the binding constant.
+func (e *Environment) SetConst(name string, val Object) Object {
+	e.store[name] = val
+	e.constants[name] = true
+	return val
+}
+
+// IsConst reports whether the binding name resolves to is a constant.
+func (e *Environment) IsConst(name string) bool {
+	if _, ok := e.store[name]; ok || e.outer == nil {
+		return e.constants[name]
+	}
+	return e.outer.IsConst(name)
+}
+
+// IsLocalConst reports whether name is a constant declared in this scope,
+// ignoring enclosing ones.
+func (e *Environment) IsLocalConst(name string) bool {
+	return e.constants[name]
+}
+
+// Assign rebinds name in the innermost scope that defines it. It reports
+// false, leaving every scope untouched, when name is not bound at all.
+func (e *Environment) Assign(name string, val Object) bool {
+	if _, ok := e.store[name]; ok {
+		e.store[name] = val
+		return true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(name, val)
+	}
+	return false
+}
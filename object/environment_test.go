@@ -0,0 +1,120 @@
+package object
+
+import "testing"
+
+func TestEnvironmentShadowing(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("x", &Integer{Value: 1})
+
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("x", &Integer{Value: 2})
+
+	testBinding(t, inner, "x", 2)
+	testBinding(t, outer, "x", 1)
+}
+
+func TestEnvironmentLookupThroughFrames(t *testing.T) {
+	global := NewEnvironment()
+	global.Set("a", &Integer{Value: 1})
+
+	middle := NewEnclosedEnvironment(global)
+	middle.Set("b", &Integer{Value: 2})
+
+	inner := NewEnclosedEnvironment(middle)
+	inner.Set("c", &Integer{Value: 3})
+
+	testBinding(t, inner, "a", 1)
+	testBinding(t, inner, "b", 2)
+	testBinding(t, inner, "c", 3)
+
+	if _, ok := middle.Get("c"); ok {
+		t.Errorf("inner binding c leaked into enclosing environment")
+	}
+}
+
+func TestEnvironmentAssign(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("x", &Integer{Value: 1})
+
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("y", &Integer{Value: 2})
+
+	if !inner.Assign("x", &Integer{Value: 10}) {
+		t.Fatalf("expected x to be assignable through the enclosing environment")
+	}
+	if !inner.Assign("y", &Integer{Value: 20}) {
+		t.Fatalf("expected y to be assignable")
+	}
+
+	testBinding(t, outer, "x", 10)
+	testBinding(t, inner, "y", 20)
+
+	if _, ok := outer.Get("y"); ok {
+		t.Errorf("assigning y leaked into enclosing environment")
+	}
+
+	if inner.Assign("missing", &Integer{Value: 1}) {
+		t.Errorf("expected assigning an unbound name to fail")
+	}
+	if _, ok := inner.Get("missing"); ok {
+		t.Errorf("failed assignment created a binding")
+	}
+}
+
+func TestEnvironmentConstants(t *testing.T) {
+	outer := NewEnvironment()
+	outer.SetConst("PI", &Integer{Value: 3})
+	outer.Set("x", &Integer{Value: 1})
+
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("x", &Integer{Value: 2})
+
+	if !outer.IsConst("PI") || !inner.IsConst("PI") {
+		t.Errorf("expected PI to be constant in both scopes")
+	}
+	if !outer.IsLocalConst("PI") {
+		t.Errorf("expected PI to be a local constant of outer")
+	}
+	if inner.IsLocalConst("PI") {
+		t.Errorf("expected PI not to be a local constant of inner")
+	}
+	if outer.IsConst("x") || inner.IsConst("x") {
+		t.Errorf("expected x not to be constant")
+	}
+
+	// A non-constant binding in an inner scope hides the constant.
+	inner.Set("PI", &Integer{Value: 4})
+	if inner.IsConst("PI") {
+		t.Errorf("expected inner PI not to be constant")
+	}
+
+	outer.Set("PI", &Integer{Value: 3})
+	if outer.IsConst("PI") {
+		t.Errorf("expected Set to clear the constant flag")
+	}
+}
+
+func TestEnvironmentUnbound(t *testing.T) {
+	env := NewEnclosedEnvironment(NewEnvironment())
+
+	if obj, ok := env.Get("missing"); ok {
+		t.Errorf("expected missing to be unbound, got=%T (%+v)", obj, obj)
+	}
+}
+
+func testBinding(t *testing.T, env *Environment, name string, expected int64) {
+	obj, ok := env.Get(name)
+	if !ok {
+		t.Errorf("%s is unbound", name)
+		return
+	}
+
+	integer, ok := obj.(*Integer)
+	if !ok {
+		t.Errorf("%s is expected=%s, got=%T", name, "*Integer", obj)
+		return
+	}
+	if integer.Value != expected {
+		t.Errorf("%s expected=%d, got=%d", name, expected, integer.Value)
+	}
+}
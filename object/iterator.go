@@ -0,0 +1,109 @@
+package object
+
+import "unicode/utf8"
+
+// Iterable is implemented by objects that for-in loops can traverse.
+type Iterable interface {
+	Iterator() Iterator
+}
+
+// Iterator produces the elements of an Iterable in order. Next returns the
+// key and value of the following element, and ok is false once there are
+// no more. Arrays, strings and ranges key their elements by position;
+// hashes use their own keys.
+type Iterator interface {
+	Next() (key, value Object, ok bool)
+}
+
+type arrayIterator struct {
+	array *Array
+	index int
+}
+
+func (a *Array) Iterator() Iterator { return &arrayIterator{array: a} }
+
+func (it *arrayIterator) Next() (Object, Object, bool) {
+	if it.index >= len(it.array.Elements) {
+		return nil, nil, false
+	}
+
+	key := &Integer{Value: int64(it.index)}
+	value := it.array.Elements[it.index]
+	it.index++
+
+	return key, value, true
+}
+
+// stringIterator yields a string one character (rune) at a time.
+type stringIterator struct {
+	value  string
+	offset int
+	index  int
+}
+
+func (s *String) Iterator() Iterator { return &stringIterator{value: s.Value} }
+
+func (it *stringIterator) Next() (Object, Object, bool) {
+	if it.offset >= len(it.value) {
+		return nil, nil, false
+	}
+
+	_, size := utf8.DecodeRuneInString(it.value[it.offset:])
+	key := &Integer{Value: int64(it.index)}
+	value := &String{Value: it.value[it.offset : it.offset+size]}
+	it.offset += size
+	it.index++
+
+	return key, value, true
+}
+
+// hashIterator yields the pairs present when iteration started, in
+// insertion order.
+type hashIterator struct {
+	hash  *Hash
+	keys  []HashKey
+	index int
+}
+
+// Keys are only ever appended, so the slice taken here is not affected
+// by pairs added during iteration.
+func (h *Hash) Iterator() Iterator { return &hashIterator{hash: h, keys: h.keys} }
+
+func (it *hashIterator) Next() (Object, Object, bool) {
+	if it.index >= len(it.keys) {
+		return nil, nil, false
+	}
+
+	pair := it.hash.pairs[it.keys[it.index]]
+	it.index++
+
+	return pair.Key, pair.Value, true
+}
+
+type rangeIterator struct {
+	rng   *Range
+	next  int64
+	index int64
+	done  bool
+}
+
+func (r *Range) Iterator() Iterator { return &rangeIterator{rng: r, next: r.Start} }
+
+func (it *rangeIterator) Next() (Object, Object, bool) {
+	if it.done || it.next > it.rng.End || it.next == it.rng.End && !it.rng.Inclusive {
+		return nil, nil, false
+	}
+
+	key := &Integer{Value: it.index}
+	value := &Integer{Value: it.next}
+	it.index++
+
+	// Stop explicitly at the last int64 instead of overflowing.
+	if it.next == it.rng.End {
+		it.done = true
+	} else {
+		it.next++
+	}
+
+	return key, value, true
+}
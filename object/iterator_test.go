@@ -0,0 +1,78 @@
+package object
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIterators(t *testing.T) {
+	hash := NewHash()
+	for _, key := range []string{"z", "y"} {
+		k := &String{Value: key}
+		hash.Set(k.HashKey(), HashPair{Key: k, Value: &String{Value: key + key}})
+	}
+
+	tests := []struct {
+		iterable Iterable
+		expected []string // key=value
+	}{
+		{&Array{Elements: []Object{&Integer{Value: 5}, TRUE}}, []string{"0=5", "1=true"}},
+		{&Array{}, []string{}},
+		{&String{Value: "héj"}, []string{"0=h", "1=é", "2=j"}},
+		{hash, []string{"z=zz", "y=yy"}},
+		{&Range{Start: 1, End: 4}, []string{"0=1", "1=2", "2=3"}},
+		{&Range{Start: 1, End: 3, Inclusive: true}, []string{"0=1", "1=2", "2=3"}},
+		{&Range{Start: 3, End: 3}, []string{}},
+		{&Range{Start: 3, End: 1, Inclusive: true}, []string{}},
+	}
+
+	for _, tt := range tests {
+		actual := []string{}
+		it := tt.iterable.Iterator()
+		for key, value, ok := it.Next(); ok; key, value, ok = it.Next() {
+			actual = append(actual, key.Inspect()+"="+value.Inspect())
+		}
+
+		if len(actual) != len(tt.expected) {
+			t.Errorf("elements expected=%v, got=%v", tt.expected, actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != tt.expected[i] {
+				t.Errorf("elements expected=%v, got=%v", tt.expected, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestHashIteratorIgnoresAddedPairs(t *testing.T) {
+	hash := NewHash()
+	one := &Integer{Value: 1}
+	hash.Set(one.HashKey(), HashPair{Key: one, Value: one})
+
+	it := hash.Iterator()
+	two := &Integer{Value: 2}
+	hash.Set(two.HashKey(), HashPair{Key: two, Value: two})
+
+	count := 0
+	for _, _, ok := it.Next(); ok; _, _, ok = it.Next() {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("number of pairs expected=%d, got=%d", 1, count)
+	}
+}
+
+func TestRangeIteratorStopsAtMaxInt64(t *testing.T) {
+	it := (&Range{Start: math.MaxInt64 - 1, End: math.MaxInt64, Inclusive: true}).Iterator()
+
+	values := []int64{}
+	for _, value, ok := it.Next(); ok && len(values) < 3; _, value, ok = it.Next() {
+		values = append(values, value.(*Integer).Value)
+	}
+
+	if len(values) != 2 || values[1] != math.MaxInt64 {
+		t.Errorf("values expected=[%d %d], got=%v", int64(math.MaxInt64-1), int64(math.MaxInt64), values)
+	}
+}
@@ -0,0 +1,112 @@
+package object
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		obj          Object
+		expectedType ObjectType
+		expected     string
+	}{
+		{&Integer{Value: 42}, INTEGER_OBJ, "42"},
+		{&Integer{Value: -7}, INTEGER_OBJ, "-7"},
+		{&BigInteger{Value: new(big.Int).Lsh(big.NewInt(1), 64)}, BIG_INTEGER_OBJ, "18446744073709551616"},
+		{&Float{Value: 3.14}, FLOAT_OBJ, "3.14"},
+		{&Float{Value: 2}, FLOAT_OBJ, "2.0"},
+		{&Float{Value: -0.5}, FLOAT_OBJ, "-0.5"},
+		{&Float{Value: 1e-9}, FLOAT_OBJ, "1e-09"},
+		{&Float{Value: 1e21}, FLOAT_OBJ, "1e+21"},
+		{&Float{Value: 0.30000000000000004}, FLOAT_OBJ, "0.30000000000000004"},
+		{&Float{Value: math.Inf(1)}, FLOAT_OBJ, "+Inf"},
+		{&Float{Value: math.NaN()}, FLOAT_OBJ, "NaN"},
+		{TRUE, BOOLEAN_OBJ, "true"},
+		{FALSE, BOOLEAN_OBJ, "false"},
+		{NULL, NULL_OBJ, "null"},
+		{&Range{Start: 0, End: 10}, RANGE_OBJ, "0..10"},
+		{&Range{Start: -2, End: 2, Inclusive: true}, RANGE_OBJ, "-2..=2"},
+		{&ReturnValue{Value: &Integer{Value: 1}}, RETURN_VALUE_OBJ, "1"},
+		{&Error{Message: "identifier not found: x"}, ERROR_OBJ, "ERROR: identifier not found: x"},
+	}
+
+	for _, tt := range tests {
+		if tt.obj.Type() != tt.expectedType {
+			t.Errorf("obj.Type() expected=%s, got=%s", tt.expectedType, tt.obj.Type())
+		}
+		if tt.obj.Inspect() != tt.expected {
+			t.Errorf("obj.Inspect() expected=%q, got=%q", tt.expected, tt.obj.Inspect())
+		}
+	}
+}
+
+func TestStringHashKey(t *testing.T) {
+	hello1 := &String{Value: "Hello World"}
+	hello2 := &String{Value: "Hello World"}
+	diff1 := &String{Value: "My name is johnny"}
+	diff2 := &String{Value: "My name is johnny"}
+
+	if hello1.HashKey() != hello2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+
+	if diff1.HashKey() != diff2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+
+	if hello1.HashKey() == diff1.HashKey() {
+		t.Errorf("strings with different content have same hash keys")
+	}
+}
+
+func TestHashKeysDistinguishTypes(t *testing.T) {
+	keys := []Hashable{
+		&Integer{Value: 1},
+		TRUE,
+		&String{Value: "1"},
+	}
+
+	seen := map[HashKey]Hashable{}
+	for _, key := range keys {
+		if other, ok := seen[key.HashKey()]; ok {
+			t.Errorf("%T and %T share hash key %+v", key, other, key.HashKey())
+		}
+		seen[key.HashKey()] = key
+	}
+
+	if (&Integer{Value: 1}).HashKey() != (&Integer{Value: 1}).HashKey() {
+		t.Errorf("integers with same value have different hash keys")
+	}
+	if (&Boolean{Value: true}).HashKey() != TRUE.HashKey() {
+		t.Errorf("booleans with same value have different hash keys")
+	}
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	h := NewHash()
+	for _, key := range []string{"b", "a", "c"} {
+		k := &String{Value: key}
+		h.Set(k.HashKey(), HashPair{Key: k, Value: &Integer{Value: 1}})
+	}
+
+	// Replacing a value keeps the key in its original position.
+	a := &String{Value: "a"}
+	h.Set(a.HashKey(), HashPair{Key: a, Value: &Integer{Value: 2}})
+
+	expected := "{b: 1, a: 2, c: 1}"
+	if h.Inspect() != expected {
+		t.Errorf("h.Inspect() expected=%q, got=%q", expected, h.Inspect())
+	}
+
+	if h.Len() != 3 {
+		t.Errorf("h.Len() expected=%d, got=%d", 3, h.Len())
+	}
+	if pair, ok := h.Get(a.HashKey()); !ok || pair.Value.Inspect() != "2" {
+		t.Errorf("h.Get(a) expected=2, got=%v (ok=%t)", pair.Value, ok)
+	}
+	if _, ok := h.Get((&String{Value: "d"}).HashKey()); ok {
+		t.Errorf("h.Get(d) expected no pair")
+	}
+}
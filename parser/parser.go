@@ -1,35 +1,83 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"github.com/arjunmayilvaganan/nibbl/ast"
+	"github.com/arjunmayilvaganan/nibbl/diagnostic"
 	"github.com/arjunmayilvaganan/nibbl/lexer"
 	"github.com/arjunmayilvaganan/nibbl/token"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // = or +=
+	RANGE       // .. or ..=
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
+	BITWISE_OR  // |
+	BITWISE_XOR // ^
+	BITWISE_AND // &
 	EQUALS      // ==
 	LESSGREATER // > or <
+	SHIFT       // << or >>
 	SUM         // +
 	PRODUCT     // *
-	PREFIX      // -X or !X
+	POWER       // **
+	PREFIX      // -X, !X or ~X
 	CALL        // myFunction(X)
+	INDEX       // array[index]
 )
 
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.ASTERISK_ASSIGN: ASSIGN,
+	token.SLASH_ASSIGN:    ASSIGN,
+	token.DOTDOT:          RANGE,
+	token.DOTDOT_EQ:       RANGE,
+	token.OR:              LOGICAL_OR,
+	token.AND:             LOGICAL_AND,
+	token.BIT_OR:          BITWISE_OR,
+	token.BIT_XOR:         BITWISE_XOR,
+	token.BIT_AND:         BITWISE_AND,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LT_EQ:           LESSGREATER,
+	token.GT_EQ:           LESSGREATER,
+	token.SHL:             SHIFT,
+	token.SHR:             SHIFT,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.PERCENT:         PRODUCT,
+	token.POWER:           POWER,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
 }
 
+// rightAssociative lists operators that group from the right, so that
+// a ** b ** c parses as a ** (b ** c).
+var rightAssociative = map[token.TokenType]bool{
+	token.ASSIGN:          true,
+	token.PLUS_ASSIGN:     true,
+	token.MINUS_ASSIGN:    true,
+	token.ASTERISK_ASSIGN: true,
+	token.SLASH_ASSIGN:    true,
+	token.POWER:           true,
+}
+
+// maxErrors is the number of errors reported before the parser gives up.
+const maxErrors = 10
+
 type (
 	prefixParseFn func() ast.Expression
 	infixParseFn  func(expression ast.Expression) ast.Expression
@@ -40,13 +88,99 @@ type Parser struct {
 	currToken token.Token
 	peekToken token.Token
 
-	errors         []string
+	lexErrors int // number of lexer diagnostics already copied into diagnostics
+
+	// panicking is set when an error is reported and cleared once the
+	// parser has synchronized on a statement boundary. Errors reported
+	// while panicking are dropped, as they are usually caused by the first.
+	panicking  bool
+	errorCount int
+	braces     int  // number of { up to and including currToken not yet closed
+	halted     bool // set once maxErrors is reached
+
+	diagnostics    []diagnostic.Diagnostic
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
 
+// Diagnostics returns everything reported while parsing, including the
+// lexer's diagnostics, in the order it was found.
+func (p *Parser) Diagnostics() []diagnostic.Diagnostic {
+	return p.diagnostics
+}
+
+// Errors returns the error diagnostics formatted as single lines.
 func (p *Parser) Errors() []string {
-	return p.errors
+	errors := []string{}
+	for _, d := range p.diagnostics {
+		if d.Severity == diagnostic.Error {
+			errors = append(errors, d.String())
+		}
+	}
+	return errors
+}
+
+// error reports a diagnostic at tok and enters panic mode. It returns nil
+// when the error is suppressed, otherwise the recorded diagnostic so that
+// hints can be attached.
+func (p *Parser) error(code diagnostic.Code, tok token.Token, format string, a ...interface{}) *diagnostic.Diagnostic {
+	if p.panicking || p.halted {
+		return nil
+	}
+	p.panicking = true
+
+	// The lexer has already reported why an ILLEGAL token is illegal.
+	if tok.Type == token.ILLEGAL {
+		return nil
+	}
+
+	return p.report(diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Pos:      tok.Pos,
+		End:      tok.End,
+	})
+}
+
+// warning reports a diagnostic at tok that does not stop the program from
+// running. Like errors, warnings are dropped while panicking.
+func (p *Parser) warning(code diagnostic.Code, tok token.Token, format string, a ...interface{}) *diagnostic.Diagnostic {
+	if p.panicking || p.halted {
+		return nil
+	}
+
+	return p.report(diagnostic.Diagnostic{
+		Severity: diagnostic.Warning,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Pos:      tok.Pos,
+		End:      tok.End,
+	})
+}
+
+func (p *Parser) report(d diagnostic.Diagnostic) *diagnostic.Diagnostic {
+	if p.halted {
+		return nil
+	}
+
+	if d.Severity == diagnostic.Error {
+		if p.errorCount == maxErrors {
+			p.halted = true
+			p.panicking = true
+			d = diagnostic.Diagnostic{
+				Severity: diagnostic.Error,
+				Code:     diagnostic.TooManyErrors,
+				Message:  fmt.Sprintf("too many errors, stopping after %d", maxErrors),
+				Pos:      d.Pos,
+				End:      d.End,
+			}
+		}
+		p.errorCount++
+	}
+
+	p.diagnostics = append(p.diagnostics, d)
+	return &p.diagnostics[len(p.diagnostics)-1]
 }
 
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
@@ -58,8 +192,11 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 }
 
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("next token type expected=%s, got=%s", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	d := p.error(diagnostic.UnexpectedToken, p.peekToken, "next token type expected=%s, got=%s", t, p.peekToken.Type)
+
+	if d != nil && p.peekTokenIs(token.EOF) {
+		d.Hints = append(d.Hints, fmt.Sprintf("the input ended while looking for %q", t))
+	}
 }
 
 func (p *Parser) peekPrecedence() int {
@@ -76,36 +213,96 @@ func (p *Parser) currPrecedence() int {
 	return LOWEST
 }
 
+// rightOperandPrecedence is the precedence the right operand of the current
+// operator is parsed at. Right-associative operators bind it slightly
+// looser, so an operator of the same precedence continues the operand.
+func (p *Parser) rightOperandPrecedence() int {
+	if rightAssociative[p.currToken.Type] {
+		return p.currPrecedence() - 1
+	}
+	return p.currPrecedence()
+}
+
 func (p *Parser) nextToken() {
 	p.currToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	switch {
+	case p.currTokenIs(token.LBRACE):
+		p.braces++
+	case p.currTokenIs(token.RBRACE) && p.braces > 0:
+		p.braces--
+	}
+
+	// Comments are only returned by lexers created with KeepComments. They
+	// are trivia to the parser, apart from the line breaks they contain.
+	for p.peekTokenIs(token.COMMENT) {
+		newline := p.peekToken.NewlineBefore || strings.ContainsAny(p.peekToken.Literal, "\r\n")
+		p.peekToken = p.l.NextToken()
+		p.peekToken.NewlineBefore = p.peekToken.NewlineBefore || newline
+	}
+
+	lexErrors := p.l.Diagnostics()
+	for ; p.lexErrors < len(lexErrors); p.lexErrors++ {
+		p.report(lexErrors[p.lexErrors])
+	}
 }
 
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{}}
+	p := &Parser{l: l, diagnostics: []diagnostic.Diagnostic{}}
 
 	// Initialize currToken and peekToken
 	p.nextToken()
 	p.nextToken()
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.CHAR, p.parseCharLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.BIT_NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
+	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.DOTDOT, p.parseInfixExpression)
+	p.registerInfix(token.DOTDOT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.BIT_AND, p.parseInfixExpression)
+	p.registerInfix(token.BIT_OR, p.parseInfixExpression)
+	p.registerInfix(token.BIT_XOR, p.parseInfixExpression)
+	p.registerInfix(token.SHL, p.parseInfixExpression)
+	p.registerInfix(token.SHR, p.parseInfixExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
 
 	return p
 }
@@ -116,15 +313,51 @@ func (p *Parser) parseIdentifier() ast.Expression {
 
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.currToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		// Too large for int64 but well formed; keep it arbitrary-precision.
+		value, _ := new(big.Int).SetString(p.currToken.Literal, 0)
+		return &ast.IntegerLiteral{Token: p.currToken, Big: value}
+	}
 	if err != nil {
-		msg := fmt.Sprintf("Cannot parse %q as integer", p.currToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.error(diagnostic.InvalidIntegerValue, p.currToken, "Cannot parse %q as integer", p.currToken.Literal)
 		return nil
 	}
 
 	return &ast.IntegerLiteral{Token: p.currToken, Value: value}
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	value, err := strconv.ParseFloat(p.currToken.Literal, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		p.error(diagnostic.FloatOutOfRange, p.currToken, "float literal %s is out of range", p.currToken.Literal)
+		return nil
+	}
+	if err != nil {
+		p.error(diagnostic.InvalidFloatValue, p.currToken, "Cannot parse %q as float", p.currToken.Literal)
+		return nil
+	}
+
+	return &ast.FloatLiteral{Token: p.currToken, Value: value}
+}
+
+// parseCharLiteral turns a character literal into an integer holding its
+// code point. The token keeps the quoted spelling for String().
+func (p *Parser) parseCharLiteral() ast.Expression {
+	value := lexer.CharValue(p.currToken.Literal)
+	return &ast.IntegerLiteral{Token: p.currToken, Value: int64(value)}
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.currToken, Value: p.currToken.Literal}
+}
+
+// parseIllegal skips an ILLEGAL token. The lexer has already reported why
+// it is illegal, so the parser only enters panic mode.
+func (p *Parser) parseIllegal() ast.Expression {
+	p.panicking = true
+	return nil
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.currToken,
@@ -161,18 +394,428 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Left:     left,
 	}
 
-	precedence := p.currPrecedence()
+	precedence := p.rightOperandPrecedence()
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
 	return expression
 }
 
+// parseAssignExpression parses the right-hand side of = and the compound
+// assignment operators. Only identifiers and index expressions can be
+// assigned to.
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	default:
+		if d := p.error(diagnostic.InvalidAssignment, p.currToken, "cannot assign to %s", target); d != nil {
+			d.Pos, d.End = target.Pos(), target.End()
+		}
+		return nil
+	}
+
+	expression := &ast.AssignExpression{
+		Token:    p.currToken,
+		Target:   target,
+		Operator: p.currToken.Literal,
+	}
+
+	precedence := p.rightOperandPrecedence()
+	p.nextToken()
+	expression.Value = p.parseExpression(precedence)
+
+	return expression
+}
+
+func (p *Parser) parseIfExpression() ast.Expression {
+	expression := &ast.IfExpression{Token: p.currToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Consequence = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.ELSE) {
+		p.nextToken()
+
+		if p.peekTokenIs(token.IF) {
+			// `else if` is sugar for an else block holding a single if expression.
+			p.nextToken()
+			block := &ast.BlockStatement{Token: p.currToken}
+			statement := &ast.ExpressionStatement{Token: p.currToken}
+			statement.Expression = p.parseIfExpression()
+			if statement.Expression == nil {
+				return nil
+			}
+			block.Statements = []ast.Statement{statement}
+			expression.Alternative = block
+			return expression
+		}
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		expression.Alternative = p.parseBlockStatement()
+	}
+
+	return expression
+}
+
+// parseMatchExpression parses match (subject) { pattern => body, ... }.
+// Arms are separated by commas or line breaks, and each may have a guard
+// written as `pattern if condition => body`.
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.currToken, Arms: []*ast.MatchArm{}}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		expression.Arms = append(expression.Arms, arm)
+
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		} else if !p.peekToken.NewlineBefore {
+			break
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	expression.Rbrace = p.currToken
+
+	p.checkExhaustive(expression)
+
+	return expression
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Pattern: p.parsePattern()}
+	if arm.Pattern == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		arm.Guard = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+
+	p.nextToken()
+	arm.Body = p.parseExpression(LOWEST)
+
+	return arm
+}
+
+// checkExhaustive warns about a match that has no arm matching every
+// value, since it fails at run time when no other arm matches.
+func (p *Parser) checkExhaustive(expression *ast.MatchExpression) {
+	for _, arm := range expression.Arms {
+		if arm.IsCatchAll() {
+			return
+		}
+	}
+
+	d := p.warning(diagnostic.NonExhaustiveMatch, expression.Token, "match has no wildcard arm")
+	if d != nil {
+		d.Hints = append(d.Hints, "add a `_ => ...` arm to handle any other value")
+	}
+}
+
+// parsePattern parses the pattern starting at currToken.
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.currToken.Type {
+	case token.WILDCARD:
+		return &ast.WildcardPattern{Token: p.currToken}
+	case token.IDENT:
+		return &ast.BindingPattern{Name: &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}}
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	}
+
+	if literal := p.parseLiteralPattern(); literal != nil {
+		return literal
+	}
+	return nil
+}
+
+// parseLiteralPattern parses a number, string, character or boolean
+// literal. Numbers may be preceded by a minus sign.
+func (p *Parser) parseLiteralPattern() *ast.LiteralPattern {
+	switch p.currToken.Type {
+	case token.INT, token.FLOAT, token.STRING, token.CHAR, token.TRUE, token.FALSE:
+		if value := p.prefixParseFns[p.currToken.Type](); value != nil {
+			return &ast.LiteralPattern{Value: value}
+		}
+		return nil
+
+	case token.MINUS:
+		if p.peekTokenIs(token.INT) || p.peekTokenIs(token.FLOAT) {
+			prefix := &ast.PrefixExpression{Token: p.currToken, Operator: p.currToken.Literal}
+			p.nextToken()
+			if prefix.Right = p.prefixParseFns[p.currToken.Type](); prefix.Right != nil {
+				return &ast.LiteralPattern{Value: prefix}
+			}
+			return nil
+		}
+	}
+
+	p.error(diagnostic.UnexpectedToken, p.currToken, "expected a pattern, got=%s", p.currToken.Type)
+	return nil
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.currToken, Elements: []ast.Pattern{}}
+
+	if !p.peekTokenIs(token.RBRACKET) {
+		for {
+			p.nextToken()
+			element := p.parsePattern()
+			if element == nil {
+				return nil
+			}
+			pattern.Elements = append(pattern.Elements, element)
+
+			if !p.peekTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	pattern.Rbracket = p.currToken
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.currToken, Pairs: []ast.HashPatternPair{}}
+
+	if !p.peekTokenIs(token.RBRACE) {
+		for {
+			p.nextToken()
+			key := p.parseLiteralPattern()
+			if key == nil {
+				return nil
+			}
+
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+
+			p.nextToken()
+			value := p.parsePattern()
+			if value == nil {
+				return nil
+			}
+			pattern.Pairs = append(pattern.Pairs, ast.HashPatternPair{Key: key, Value: value})
+
+			if !p.peekTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	pattern.Rbrace = p.currToken
+
+	return pattern
+}
+
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	literal := &ast.FunctionLiteral{Token: p.currToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	literal.Parameters = p.parseFunctionParameters()
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	literal.Body = p.parseBlockStatement()
+
+	return literal
+}
+
+func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return identifiers
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	identifiers = append(identifiers, &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal})
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		identifiers = append(identifiers, &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal})
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return identifiers
+}
+
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	expression := &ast.CallExpression{Token: p.currToken, Function: function}
+	expression.Arguments = p.parseExpressionList(token.RPAREN)
+	if expression.Arguments != nil {
+		expression.Rparen = p.currToken
+	}
+	return expression
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.currToken}
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if array.Elements != nil {
+		array.Rbracket = p.currToken
+	}
+	return array
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IndexExpression{Token: p.currToken, Left: left}
+
+	p.nextToken()
+	expression.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	expression.Rbracket = p.currToken
+
+	return expression
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.currToken, Pairs: []ast.HashLiteralPair{}}
+
+	if p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		hash.Rbrace = p.currToken
+		return hash
+	}
+
+	p.nextToken()
+	return p.parseHashPairs(hash, p.parseExpression(LOWEST))
+}
+
+// parseHashPairs parses the pairs of a hash literal whose first key has
+// already been parsed, up to and including the closing brace.
+func (p *Parser) parseHashPairs(hash *ast.HashLiteral, key ast.Expression) ast.Expression {
+	for {
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+		hash.Pairs = append(hash.Pairs, ast.HashLiteralPair{Key: key, Value: value})
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		p.nextToken()
+		p.nextToken()
+		key = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	hash.Rbrace = p.currToken
+
+	return hash
+}
+
+// parseExpressionList parses comma separated expressions up to and
+// including the end token, as used by call arguments and array literals.
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+
+	p.nextToken()
+	list = append(list, p.parseExpression(LOWEST))
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 
-	for p.currToken.Type != token.EOF {
+	for !p.currTokenIs(token.EOF) && !p.halted {
 		statement := p.parseStatement()
 		if statement != nil {
 			program.Statements = append(program.Statements, statement)
@@ -183,18 +826,89 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// parseStatement parses the statement at currToken. If an error is
+// reported along the way the statement is dropped and the parser skips
+// ahead to the next statement boundary.
 func (p *Parser) parseStatement() ast.Statement {
+	var statement ast.Statement
+
+	depth := p.braces
+	if p.currTokenIs(token.LBRACE) {
+		depth--
+	}
+
 	switch p.currToken.Type {
-	case token.LET:
-		return p.parseLetStatement()
+	case token.LET, token.CONST:
+		statement = p.parseLetStatement()
 	case token.RETURN:
-		return p.parseReturnStatement()
+		statement = p.parseReturnStatement()
+	case token.WHILE:
+		statement = p.parseWhileStatement()
+	case token.FOR:
+		statement = p.parseForStatement()
+	case token.BREAK:
+		statement = &ast.BreakStatement{Token: p.currToken}
+		p.expectStatementEnd()
+	case token.CONTINUE:
+		statement = &ast.ContinueStatement{Token: p.currToken}
+		p.expectStatementEnd()
+	case token.LBRACE:
+		statement = p.parseBraceStatement()
 	default:
-		return p.parseExpressionStatement()
+		statement = p.parseExpressionStatement()
+	}
+
+	if p.panicking {
+		p.synchronize(depth)
+		return nil
+	}
+
+	return statement
+}
+
+// synchronize skips tokens until the end of the current statement, which
+// started with depth braces open: a semicolon, or just before a statement
+// keyword or the closing brace of the enclosing block. Braces opened
+// within the statement are skipped over along with their contents. It
+// never advances past token.EOF.
+func (p *Parser) synchronize(depth int) {
+	if !p.halted {
+		p.panicking = false
+	}
+
+	for !p.currTokenIs(token.EOF) {
+		if p.braces <= depth && (p.currTokenIs(token.SEMICOLON) || isStatementBoundary(p.peekToken.Type)) {
+			return
+		}
+
+		p.nextToken()
+	}
+}
+
+func isStatementBoundary(t token.TokenType) bool {
+	switch t {
+	case token.LET, token.CONST, token.RETURN, token.WHILE, token.FOR,
+		token.BREAK, token.CONTINUE, token.RBRACE, token.EOF:
+		return true
+	default:
+		return false
 	}
 }
 
 func (p *Parser) parseLetStatement() *ast.LetStatement {
+	statement := p.parseLetBinding()
+	if statement == nil {
+		return nil
+	}
+
+	p.expectStatementEnd()
+
+	return statement
+}
+
+// parseLetBinding parses a let or const declaration without its
+// terminator, as used in for loop headers.
+func (p *Parser) parseLetBinding() *ast.LetStatement {
 	statement := &ast.LetStatement{Token: p.currToken}
 
 	if !p.expectPeek(token.IDENT) {
@@ -210,10 +924,126 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	p.nextToken()
 	statement.Value = p.parseExpression(LOWEST)
 
-	for !p.currTokenIs(token.SEMICOLON) {
+	return statement
+}
+
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	statement := &ast.WhileStatement{Token: p.currToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	statement.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = p.parseBlockStatement()
+
+	p.expectStatementEnd()
+
+	return statement
+}
+
+// parseForStatement parses for (init; condition; post) { ... }, where
+// each of the three clauses may be left empty, and the for-in forms
+// for (x in collection) { ... } and for (k, v in collection) { ... }.
+func (p *Parser) parseForStatement() ast.Statement {
+	forToken := p.currToken
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	if p.currTokenIs(token.IDENT) && (p.peekTokenIs(token.IN) || p.peekTokenIs(token.COMMA)) {
+		return p.parseForInStatement(forToken)
+	}
+
+	statement := &ast.ForStatement{Token: forToken}
+
+	if !p.currTokenIs(token.SEMICOLON) {
+		if p.currTokenIs(token.LET) || p.currTokenIs(token.CONST) {
+			statement.Init = p.parseLetBinding()
+		} else {
+			statement.Init = &ast.ExpressionStatement{Token: p.currToken, Expression: p.parseExpression(LOWEST)}
+		}
+
+		if !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	if !p.currTokenIs(token.SEMICOLON) {
+		statement.Condition = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	if !p.currTokenIs(token.RPAREN) {
+		statement.Post = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = p.parseBlockStatement()
+
+	p.expectStatementEnd()
+
+	return statement
+}
+
+// parseForInStatement parses the rest of a for-in loop header, starting
+// at its first variable.
+func (p *Parser) parseForInStatement(forToken token.Token) *ast.ForInStatement {
+	statement := &ast.ForInStatement{Token: forToken}
+	statement.Value = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+
+	if p.peekTokenIs(token.COMMA) {
 		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		statement.Key = statement.Value
+		statement.Value = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	statement.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
 	}
 
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = p.parseBlockStatement()
+
+	p.expectStatementEnd()
+
 	return statement
 }
 
@@ -224,9 +1054,7 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	statement.ReturnValue = p.parseExpression(LOWEST)
 
-	for !p.currTokenIs(token.SEMICOLON) {
-		p.nextToken()
-	}
+	p.expectStatementEnd()
 
 	return statement
 }
@@ -236,11 +1064,105 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 	statement.Expression = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
+	p.expectStatementEnd()
+
+	return statement
+}
+
+// expectStatementEnd consumes the terminator of the statement ending at
+// currToken. A semicolon may be omitted when the next token starts a new
+// line, closes the enclosing block or ends the input, or when the
+// statement itself ends with a block.
+func (p *Parser) expectStatementEnd() {
+	switch {
+	case p.peekTokenIs(token.SEMICOLON):
 		p.nextToken()
+	case p.peekToken.NewlineBefore,
+		p.peekTokenIs(token.RBRACE),
+		p.peekTokenIs(token.EOF),
+		p.currTokenIs(token.RBRACE):
+		return
+	default:
+		d := p.error(diagnostic.MissingTerminator, p.peekToken,
+			"expected ; or newline after statement, got=%s", p.peekToken.Type)
+		if d != nil {
+			d.Hints = append(d.Hints, "put each statement on its own line or separate them with ;")
+		}
 	}
+}
 
-	return statement
+// parseBraceStatement disambiguates a statement that starts with `{`.
+// It is a hash literal when empty or when its first expression is
+// followed by a colon, and a block statement otherwise.
+func (p *Parser) parseBraceStatement() ast.Statement {
+	brace := p.currToken
+
+	if p.peekTokenIs(token.RBRACE) {
+		return p.parseExpressionStatement()
+	}
+
+	switch p.peekToken.Type {
+	case token.LET, token.CONST, token.RETURN, token.WHILE, token.FOR,
+		token.BREAK, token.CONTINUE, token.LBRACE:
+		block := p.parseBlockStatement()
+		p.expectStatementEnd()
+		return block
+	}
+
+	p.nextToken()
+	first := &ast.ExpressionStatement{Token: p.currToken}
+	first.Expression = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.COLON) {
+		statement := &ast.ExpressionStatement{Token: brace}
+		hash := &ast.HashLiteral{Token: brace, Pairs: []ast.HashLiteralPair{}}
+		if exp := p.parseHashPairs(hash, first.Expression); exp != nil {
+			statement.Expression = p.parseInfixExpressions(LOWEST, exp)
+		}
+		p.expectStatementEnd()
+		return statement
+	}
+
+	p.expectStatementEnd()
+
+	block := &ast.BlockStatement{Token: brace, Statements: []ast.Statement{first}}
+	p.nextToken()
+	p.parseBlockBody(block)
+
+	p.expectStatementEnd()
+
+	return block
+}
+
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.currToken}
+	block.Statements = []ast.Statement{}
+
+	p.nextToken()
+	p.parseBlockBody(block)
+
+	return block
+}
+
+// parseBlockBody appends statements to block until the closing brace,
+// reporting an error if the input ends first.
+func (p *Parser) parseBlockBody(block *ast.BlockStatement) {
+	for !p.currTokenIs(token.RBRACE) && !p.currTokenIs(token.EOF) && !p.halted {
+		statement := p.parseStatement()
+		if statement != nil {
+			block.Statements = append(block.Statements, statement)
+		}
+		p.nextToken()
+	}
+
+	if p.currTokenIs(token.RBRACE) {
+		block.Rbrace = p.currToken
+	} else if p.currTokenIs(token.EOF) {
+		d := p.error(diagnostic.UnexpectedToken, p.currToken, "expected }, got=%s", p.currToken.Type)
+		if d != nil {
+			d.Hints = append(d.Hints, fmt.Sprintf("the block opened at %s is never closed", block.Token.Pos))
+		}
+	}
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
@@ -249,9 +1171,15 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		p.noPrefixParseFnError(p.currToken.Type)
 		return nil
 	}
-	leftExp := prefix()
 
-	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
+	return p.parseInfixExpressions(precedence, prefix())
+}
+
+// parseInfixExpressions extends leftExp with any infix operators binding
+// tighter than precedence. An operator at the start of a line is never
+// an infix continuation, since the line break ends the statement.
+func (p *Parser) parseInfixExpressions(precedence int, leftExp ast.Expression) ast.Expression {
+	for !p.peekTokenIs(token.SEMICOLON) && !p.peekToken.NewlineBefore && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
 			return leftExp
@@ -265,8 +1193,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 }
 
 func (p *Parser) noPrefixParseFnError(tokenType token.TokenType) {
-	msg := fmt.Sprintf("No prefix parse function for %s found", tokenType)
-	p.errors = append(p.errors, msg)
+	p.error(diagnostic.MissingExpression, p.currToken, "No prefix parse function for %s found", tokenType)
 }
 
 func (p *Parser) currTokenIs(t token.TokenType) bool {
@@ -3,9 +3,12 @@ package parser
 import (
 	"fmt"
 	"github.com/arjunmayilvaganan/nibbl/ast"
+	"github.com/arjunmayilvaganan/nibbl/diagnostic"
 	"github.com/arjunmayilvaganan/nibbl/lexer"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func checkParserErrors(t *testing.T, p *Parser) {
@@ -56,6 +59,44 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+		expectedString     string
+	}{
+		{"const PI = 3;", "PI", 3, "const PI = 3;"},
+		{"const name = x\n", "name", "x", "const name = x;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("Number of statements expected=%d, got=%d", 1, len(program.Statements))
+		}
+
+		statement, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("statement is expected=%s, got=%T", "*ast.LetStatement", program.Statements[0])
+		}
+		if !statement.IsConst() {
+			t.Errorf("statement.IsConst() expected=%t, got=%t", true, statement.IsConst())
+		}
+		if statement.Name.Value != tt.expectedIdentifier {
+			t.Errorf("statement.Name.Value expected=%s, got=%s", tt.expectedIdentifier, statement.Name.Value)
+		}
+		testLiteralExpression(t, statement.Value, tt.expectedValue)
+		if program.String() != tt.expectedString {
+			t.Errorf("program.String() expected=%q, got=%q", tt.expectedString, program.String())
+		}
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -155,6 +196,138 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestIntegerLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xFF", 255},
+		{"0Xff", 255},
+		{"0o17", 15},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"0x_dead_beef", 0xdeadbeef},
+		{"9223372036854775807", 9223372036854775807},
+		{"'a'", 97},
+		{`'\n'`, 10},
+		{"'é'", 233},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		s := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := s.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("literal is expected=%s, got=%T", "*ast.IntegerLiteral", s.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value expected=%d, got=%d", tt.expected, literal.Value)
+		}
+		if literal.String() != tt.input {
+			t.Errorf("literal.String() expected=%s, got=%s", tt.input, literal.String())
+		}
+	}
+}
+
+func TestBigIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775808", "9223372036854775808"},
+		{"0xFFFFFFFFFFFFFFFFF", "295147905179352825855"},
+		{"1_000_000_000_000_000_000_000", "1000000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		s := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := s.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("literal is expected=%s, got=%T", "*ast.IntegerLiteral", s.Expression)
+		}
+		if literal.Big == nil {
+			t.Fatalf("literal.Big is nil for %s", tt.input)
+		}
+		if literal.Big.String() != tt.expected {
+			t.Errorf("literal.Big expected=%s, got=%s", tt.expected, literal.Big)
+		}
+		if literal.String() != tt.input {
+			t.Errorf("literal.String() expected=%s, got=%s", tt.input, literal.String())
+		}
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14", 3.14},
+		{"1e-9", 1e-9},
+		{"2E10", 2e10},
+		{"1_000.5", 1000.5},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		s := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := s.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("literal is expected=%s, got=%T", "*ast.FloatLiteral", s.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value expected=%g, got=%g", tt.expected, literal.Value)
+		}
+		if literal.String() != tt.input {
+			t.Errorf("literal.String() expected=%s, got=%s", tt.input, literal.String())
+		}
+	}
+}
+
+func TestInvalidIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedCode  diagnostic.Code
+		expectedError string
+	}{
+		{"0b102", diagnostic.InvalidIntegerValue, `Cannot parse "0b102" as integer at 1:1`},
+		{"1__0", diagnostic.InvalidIntegerValue, `Cannot parse "1__0" as integer at 1:1`},
+		{"12ab", diagnostic.InvalidIntegerValue, `Cannot parse "12ab" as integer at 1:1`},
+		{"1e400", diagnostic.FloatOutOfRange, "float literal 1e400 is out of range at 1:1"},
+		{"1.5_", diagnostic.InvalidFloatValue, `Cannot parse "1.5_" as float at 1:1`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		diagnostics := p.Diagnostics()
+		if len(diagnostics) != 1 {
+			t.Fatalf("number of diagnostics for %q expected=%d, got=%d (%v)", tt.input, 1, len(diagnostics), p.Errors())
+		}
+		if diagnostics[0].Code != tt.expectedCode {
+			t.Errorf("code expected=%s, got=%s", tt.expectedCode, diagnostics[0].Code)
+		}
+		if diagnostics[0].String() != tt.expectedError {
+			t.Errorf("error expected=%q, got=%q", tt.expectedError, diagnostics[0].String())
+		}
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input           string
@@ -193,6 +366,294 @@ func TestBooleanExpression(t *testing.T) {
 	}
 }
 
+func TestStringLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		str      string
+	}{
+		{`"hello world";`, "hello world", `"hello world"`},
+		{`"tab\tnew\nline";`, "tab\tnew\nline", `"tab\tnew\nline"`},
+		{`"say \"hi\" \\o/";`, `say "hi" \o/`, `"say \"hi\" \\o/"`},
+		{`"\u{48}\u{1F600}";`, "H\U0001F600", "\"H\U0001F600\""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		s := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := s.Expression.(*ast.StringLiteral)
+		if !ok {
+			t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.StringLiteral", s.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value expected=%q, got=%q", tt.expected, literal.Value)
+		}
+		if literal.String() != tt.str {
+			t.Errorf("literal.String() expected=%s, got=%s", tt.str, literal.String())
+		}
+	}
+}
+
+func TestLexerErrorsReported(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`s + "never closed;`, "unterminated string literal at 1:5"},
+		{`"bad \q escape";`, "unknown escape sequence \\q at 1:6"},
+		{`@;`, "illegal character '@' at 1:1"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("Number of errors for %q expected=%d, got=%d (%v)", tt.input, 1, len(errors), errors)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("error expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestArrayLiteralParsing(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	s, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("s is expected=%s, got=%T", "*ast.ExpressionStatement", program.Statements[0])
+	}
+
+	array, ok := s.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.ArrayLiteral", s.Expression)
+	}
+
+	if len(array.Elements) != 3 {
+		t.Fatalf("Number of elements expected=%d, got=%d", 3, len(array.Elements))
+	}
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestEmptyArrayLiteralParsing(t *testing.T) {
+	input := "[]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	s := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := s.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.ArrayLiteral", s.Expression)
+	}
+
+	if len(array.Elements) != 0 {
+		t.Errorf("Number of elements expected=%d, got=%d", 0, len(array.Elements))
+	}
+}
+
+func TestIndexExpressionParsing(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	s := program.Statements[0].(*ast.ExpressionStatement)
+	indexExp, ok := s.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.IndexExpression", s.Expression)
+	}
+
+	if !testIdentifier(t, indexExp.Left, "myArray") {
+		return
+	}
+
+	if !testInfixExpression(t, indexExp.Index, 1, "+", 1) {
+		return
+	}
+}
+
+func TestArrayRoundTrip(t *testing.T) {
+	tests := []string{
+		"[1, 2, [3, 4]]",
+		"(myArray[(1 + 1)])",
+		"(f()[0])",
+		`let xs = ["a", "b"];`,
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != input {
+			t.Errorf("expected=%s, got=%s", input, program.String())
+		}
+	}
+}
+
+func TestHashLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]int64
+	}{
+		{`let h = {"one": 1, "two": 2, "three": 3};`, map[string]int64{"one": 1, "two": 2, "three": 3}},
+		{`{"one": 1, "two": 2, "three": 3}`, map[string]int64{"one": 1, "two": 2, "three": 3}},
+		{`{"one": 1}`, map[string]int64{"one": 1}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		var exp ast.Expression
+		switch s := program.Statements[0].(type) {
+		case *ast.LetStatement:
+			exp = s.Value
+		case *ast.ExpressionStatement:
+			exp = s.Expression
+		}
+
+		hash, ok := exp.(*ast.HashLiteral)
+		if !ok {
+			t.Fatalf("exp is expected=%s, got=%T", "*ast.HashLiteral", exp)
+		}
+
+		if len(hash.Pairs) != len(tt.expected) {
+			t.Errorf("Number of pairs expected=%d, got=%d", len(tt.expected), len(hash.Pairs))
+		}
+
+		for _, pair := range hash.Pairs {
+			literal, ok := pair.Key.(*ast.StringLiteral)
+			if !ok {
+				t.Errorf("key is expected=%s, got=%T", "*ast.StringLiteral", pair.Key)
+				continue
+			}
+
+			testIntegerLiteral(t, pair.Value, tt.expected[literal.Value])
+		}
+	}
+}
+
+func TestEmptyHashLiteralParsing(t *testing.T) {
+	input := "{}"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	s := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := s.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.HashLiteral", s.Expression)
+	}
+
+	if len(hash.Pairs) != 0 {
+		t.Errorf("Number of pairs expected=%d, got=%d", 0, len(hash.Pairs))
+	}
+}
+
+func TestHashLiteralsWithExpressions(t *testing.T) {
+	input := `let h = {"one": 0 + 1, "two": 10 - 8, "three": 15 / 5};`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	hash, ok := program.Statements[0].(*ast.LetStatement).Value.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("Value is expected=%s, got=%T", "*ast.HashLiteral", program.Statements[0])
+	}
+
+	tests := map[string]func(ast.Expression){
+		"one": func(e ast.Expression) {
+			testInfixExpression(t, e, 0, "+", 1)
+		},
+		"two": func(e ast.Expression) {
+			testInfixExpression(t, e, 10, "-", 8)
+		},
+		"three": func(e ast.Expression) {
+			testInfixExpression(t, e, 15, "/", 5)
+		},
+	}
+
+	for _, pair := range hash.Pairs {
+		literal := pair.Key.(*ast.StringLiteral)
+		testFunc, ok := tests[literal.Value]
+		if !ok {
+			t.Errorf("No test function for key %q found", literal.Value)
+			continue
+		}
+
+		testFunc(pair.Value)
+	}
+}
+
+func TestBraceStatementDisambiguation(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType string
+		expected     string
+	}{
+		{"{}", "*ast.ExpressionStatement", "{}"},
+		{"{ x: 1 }", "*ast.ExpressionStatement", "{x: 1}"},
+		{"{ 1 + 1: 2, true: 3 }", "*ast.ExpressionStatement", "{(1 + 1): 2, true: 3}"},
+		{"{ x: 1 }[x]", "*ast.ExpressionStatement", "({x: 1}[x])"},
+		{"{ x }", "*ast.BlockStatement", "{ x; }"},
+		{"{ x; y }", "*ast.BlockStatement", "{ x; y; }"},
+		{"{ let x = 1; x }", "*ast.BlockStatement", "{ let x = 1; x; }"},
+		{"{ { a: 1 } }", "*ast.BlockStatement", "{ {a: 1}; }"},
+		{"{ { a } }", "*ast.BlockStatement", "{ { a; } }"},
+		{"{ const x = 1 }", "*ast.BlockStatement", "{ const x = 1; }"},
+		{"{ while (x) { break } }", "*ast.BlockStatement", "{ while (x) { break; } }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("Number of statements for %q expected=%d, got=%d",
+				tt.input, 1, len(program.Statements))
+		}
+
+		actualType := fmt.Sprintf("%T", program.Statements[0])
+		if actualType != tt.expectedType {
+			t.Errorf("statement for %q expected=%s, got=%s", tt.input, tt.expectedType, actualType)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("expected=%s, got=%s", tt.expected, program.String())
+		}
+	}
+}
+
 func TestPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -237,7 +698,93 @@ func TestPrefixExpressions(t *testing.T) {
 	}
 }
 
-func TestInfixExpressions(t *testing.T) {
+func TestBitwiseNotExpression(t *testing.T) {
+	l := lexer.New("~5;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	s := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := s.Expression.(*ast.PrefixExpression)
+	if !ok {
+		t.Fatalf("exp is expected=%s, got=%T", "*ast.PrefixExpression", s.Expression)
+	}
+	if exp.Operator != "~" {
+		t.Errorf("exp.Operator expected=%s, got=%s", "~", exp.Operator)
+	}
+	testIntegerLiteral(t, exp.Right, 5)
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		target   string
+		operator string
+		value    interface{}
+	}{
+		{"x = 5;", "x", "=", 5},
+		{"x += 5;", "x", "+=", 5},
+		{"x -= y", "x", "-=", "y"},
+		{"x *= 2", "x", "*=", 2},
+		{"x /= 2", "x", "/=", 2},
+		{"arr[1] = 5", "(arr[1])", "=", 5},
+		{"h[\"k\"] += 1", "(h[\"k\"])", "+=", 1},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("Number of statements expected=%d, got=%d", 1, len(program.Statements))
+		}
+
+		s := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := s.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("exp is expected=%s, got=%T", "*ast.AssignExpression", s.Expression)
+		}
+		if exp.Target.String() != tt.target {
+			t.Errorf("exp.Target expected=%s, got=%s", tt.target, exp.Target.String())
+		}
+		if exp.Operator != tt.operator {
+			t.Errorf("exp.Operator expected=%s, got=%s", tt.operator, exp.Operator)
+		}
+		testLiteralExpression(t, exp.Value, tt.value)
+	}
+}
+
+func TestInvalidAssignmentTarget(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"1 = 2;", "cannot assign to 1 at 1:1"},
+		{"f() += 1;", "cannot assign to f() at 1:1"},
+		{"let x = 1; (x + 1) = 2;", "cannot assign to (x + 1) at 1:13"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		diagnostics := p.Diagnostics()
+		if len(diagnostics) != 1 {
+			t.Fatalf("number of diagnostics for %q expected=%d, got=%d (%v)", tt.input, 1, len(diagnostics), p.Errors())
+		}
+		if diagnostics[0].Code != diagnostic.InvalidAssignment {
+			t.Errorf("code expected=%s, got=%s", diagnostic.InvalidAssignment, diagnostics[0].Code)
+		}
+		if diagnostics[0].String() != tt.expectedError {
+			t.Errorf("error expected=%q, got=%q", tt.expectedError, diagnostics[0].String())
+		}
+	}
+}
+
+func TestInfixExpressions(t *testing.T) {
 	infixTests := []struct {
 		input      string
 		leftValue  interface{}
@@ -252,6 +799,17 @@ func TestInfixExpressions(t *testing.T) {
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"true && false", true, "&&", false},
+		{"true || false", true, "||", false},
+		{"5 & 5;", 5, "&", 5},
+		{"5 | 5;", 5, "|", 5},
+		{"5 ^ 5;", 5, "^", 5},
+		{"5 << 5;", 5, "<<", 5},
+		{"5 >> 5;", 5, ">>", 5},
+		{"5 ** 5;", 5, "**", 5},
 		{"foobar + barfoo;", "foobar", "+", "barfoo"},
 		{"foobar - barfoo;", "foobar", "-", "barfoo"},
 		{"foobar * barfoo;", "foobar", "*", "barfoo"},
@@ -297,6 +855,82 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"1 + (2 + 3) + 4",
 			"((1 + (2 + 3)) + 4)",
 		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a == b && c != d || !e",
+			"(((a == b) && (c != d)) || (!e))",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b == c",
+			"(a & (b == c))",
+		},
+		{
+			"a << 1 + 2 < b >> c",
+			"((a << (1 + 2)) < (b >> c))",
+		},
+		{
+			"a | b && c | d",
+			"((a | b) && (c | d))",
+		},
+		{
+			"~a & -b",
+			"((~a) & (-b))",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"a * b ** c * d",
+			"((a * (b ** c)) * d)",
+		},
+		{
+			"a ** b * c ** d",
+			"((a ** b) * (c ** d))",
+		},
+		{
+			"-a ** 2",
+			"((-a) ** 2)",
+		},
+		{
+			"a ** -b",
+			"(a ** (-b))",
+		},
+		{
+			"a - b - c",
+			"((a - b) - c)",
+		},
+		{
+			"x = y = 1 + 2",
+			"(x = (y = (1 + 2)))",
+		},
+		{
+			"x += a || b",
+			"(x += (a || b))",
+		},
+		{
+			"arr[i + 1] *= 2 ** 3",
+			"((arr[(i + 1)]) *= (2 ** 3))",
+		},
 		{
 			"(5 + 5) * 2",
 			"((5 + 5) * 2)",
@@ -379,6 +1013,221 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"3 < 5 == true",
 			"((3 < 5) == true)",
 		},
+		{
+			"a + add(b * c) + d",
+			"((a + add((b * c))) + d)",
+		},
+		{
+			"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
+			"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
+		},
+		{
+			"add(a + b + c * d / f + g)",
+			"add((((a + b) + ((c * d) / f)) + g))",
+		},
+		{
+			"fn(x) { x; }(5)",
+			"fn(x) { x; }(5)",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"f()[0]",
+			"(f()[0])",
+		},
+		{
+			"-a[0]",
+			"(-(a[0]))",
+		},
+		{
+			"a[0][1]",
+			"((a[0])[1])",
+		},
+		{
+			"0..n + 1",
+			"(0 .. (n + 1))",
+		},
+		{
+			"a..=b == c",
+			"(a ..= (b == c))",
+		},
+		{
+			"x = 1..2 || y",
+			"(x = (1 .. (2 || y)))",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%s, got=%s", tt.expected, actual)
+		}
+	}
+}
+
+func TestIfExpression(t *testing.T) {
+	input := `if (x < y) { x }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Number of statements expected=%d, got=%d",
+			1, len(program.Statements))
+	}
+
+	s, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("s is expected=%s, got=%T", "*ast.ExpressionStatement", program.Statements[0])
+	}
+
+	expression, ok := s.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.IfExpression", s.Expression)
+	}
+
+	if !testInfixExpression(t, expression.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(expression.Consequence.Statements) != 1 {
+		t.Errorf("Number of consequence statements expected=%d, got=%d",
+			1, len(expression.Consequence.Statements))
+	}
+
+	consequence, ok := expression.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("consequence is expected=%s, got=%T",
+			"*ast.ExpressionStatement", expression.Consequence.Statements[0])
+	}
+
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+
+	if expression.Alternative != nil {
+		t.Errorf("expression.Alternative expected=nil, got=%+v", expression.Alternative)
+	}
+}
+
+func TestIfElseExpression(t *testing.T) {
+	input := `if (x < y) { x } else { y }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Number of statements expected=%d, got=%d",
+			1, len(program.Statements))
+	}
+
+	s, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("s is expected=%s, got=%T", "*ast.ExpressionStatement", program.Statements[0])
+	}
+
+	expression, ok := s.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.IfExpression", s.Expression)
+	}
+
+	if !testInfixExpression(t, expression.Condition, "x", "<", "y") {
+		return
+	}
+
+	consequence, ok := expression.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("consequence is expected=%s, got=%T",
+			"*ast.ExpressionStatement", expression.Consequence.Statements[0])
+	}
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+
+	if len(expression.Alternative.Statements) != 1 {
+		t.Errorf("Number of alternative statements expected=%d, got=%d",
+			1, len(expression.Alternative.Statements))
+	}
+
+	alternative, ok := expression.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("alternative is expected=%s, got=%T",
+			"*ast.ExpressionStatement", expression.Alternative.Statements[0])
+	}
+	if !testIdentifier(t, alternative.Expression, "y") {
+		return
+	}
+}
+
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < y) { x } else if (x > y) { y } else { z }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	s := program.Statements[0].(*ast.ExpressionStatement)
+	expression, ok := s.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.IfExpression", s.Expression)
+	}
+
+	if len(expression.Alternative.Statements) != 1 {
+		t.Fatalf("Number of alternative statements expected=%d, got=%d",
+			1, len(expression.Alternative.Statements))
+	}
+
+	alternative := expression.Alternative.Statements[0].(*ast.ExpressionStatement)
+	nested, ok := alternative.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("alternative is expected=%s, got=%T", "*ast.IfExpression", alternative.Expression)
+	}
+
+	if !testInfixExpression(t, nested.Condition, "x", ">", "y") {
+		return
+	}
+	if nested.Alternative == nil {
+		t.Fatalf("nested.Alternative expected non-nil")
+	}
+}
+
+func TestIfExpressionRoundTrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"if (x) { y }",
+			"if (x) { y; }",
+		},
+		{
+			"if (x < y) { x } else { y }",
+			"if ((x < y)) { x; } else { y; }",
+		},
+		{
+			"if (a) { let b = 1; b } else if (c) { d } else { e }",
+			"if (a) { let b = 1; b; } else if (c) { d; } else { e; }",
+		},
+		{
+			"let max = fn(a, b) { if (a > b) { return a; } b };",
+			"let max = fn(a, b) { if ((a > b)) { return a; }; b; };",
+		},
 	}
 
 	for _, tt := range tests {
@@ -391,6 +1240,953 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		if actual != tt.expected {
 			t.Errorf("expected=%s, got=%s", tt.expected, actual)
 		}
+
+		l = lexer.New(actual)
+		p = New(l)
+		reparsed := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if reparsed.String() != actual {
+			t.Errorf("round trip expected=%s, got=%s", actual, reparsed.String())
+		}
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < 10) { x += 1 }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Number of statements expected=%d, got=%d", 1, len(program.Statements))
+	}
+
+	statement, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("statement is expected=%s, got=%T", "*ast.WhileStatement", program.Statements[0])
+	}
+	if !testInfixExpression(t, statement.Condition, "x", "<", 10) {
+		return
+	}
+	if len(statement.Body.Statements) != 1 {
+		t.Errorf("Number of body statements expected=%d, got=%d", 1, len(statement.Body.Statements))
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	tests := []struct {
+		input        string
+		hasInit      bool
+		hasCondition bool
+		hasPost      bool
+	}{
+		{"for (let i = 0; i < 10; i += 1) { x }", true, true, true},
+		{"for (i = 0; i < 10; i += 1) { x }", true, true, true},
+		{"for (; i < 10; ) { x }", false, true, false},
+		{"for (;;) { break }", false, false, false},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("Number of statements expected=%d, got=%d", 1, len(program.Statements))
+		}
+
+		statement, ok := program.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("statement is expected=%s, got=%T", "*ast.ForStatement", program.Statements[0])
+		}
+		if (statement.Init != nil) != tt.hasInit {
+			t.Errorf("%q: statement.Init expected present=%t, got=%v", tt.input, tt.hasInit, statement.Init)
+		}
+		if (statement.Condition != nil) != tt.hasCondition {
+			t.Errorf("%q: statement.Condition expected present=%t, got=%v", tt.input, tt.hasCondition, statement.Condition)
+		}
+		if (statement.Post != nil) != tt.hasPost {
+			t.Errorf("%q: statement.Post expected present=%t, got=%v", tt.input, tt.hasPost, statement.Post)
+		}
+		if len(statement.Body.Statements) != 1 {
+			t.Errorf("Number of body statements expected=%d, got=%d", 1, len(statement.Body.Statements))
+		}
+	}
+}
+
+func TestForInStatement(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedKey      string
+		expectedValue    string
+		expectedIterable string
+	}{
+		{"for (x in xs) { x }", "", "x", "xs"},
+		{"for (k, v in h) { v }", "k", "v", "h"},
+		{"for (i in 0..n + 1) { i }", "", "i", "(0 .. (n + 1))"},
+		{"for (c in \"abc\") { c }", "", "c", "\"abc\""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("Number of statements expected=%d, got=%d", 1, len(program.Statements))
+		}
+
+		statement, ok := program.Statements[0].(*ast.ForInStatement)
+		if !ok {
+			t.Fatalf("statement is expected=%s, got=%T", "*ast.ForInStatement", program.Statements[0])
+		}
+		if tt.expectedKey == "" && statement.Key != nil {
+			t.Errorf("%q: statement.Key expected=nil, got=%s", tt.input, statement.Key)
+		}
+		if tt.expectedKey != "" && (statement.Key == nil || statement.Key.Value != tt.expectedKey) {
+			t.Errorf("%q: statement.Key expected=%s, got=%v", tt.input, tt.expectedKey, statement.Key)
+		}
+		if statement.Value.Value != tt.expectedValue {
+			t.Errorf("%q: statement.Value expected=%s, got=%s", tt.input, tt.expectedValue, statement.Value.Value)
+		}
+		if statement.Iterable.String() != tt.expectedIterable {
+			t.Errorf("%q: statement.Iterable expected=%s, got=%s", tt.input, tt.expectedIterable, statement.Iterable.String())
+		}
+		if len(statement.Body.Statements) != 1 {
+			t.Errorf("Number of body statements expected=%d, got=%d", 1, len(statement.Body.Statements))
+		}
+	}
+}
+
+func TestLoopRoundTrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"while (x < 3) { x += 1 }",
+			"while ((x < 3)) { (x += 1); }",
+		},
+		{
+			"for (let i = 0; i < 3; i += 1) { if (i == 1) { continue } f(i) }",
+			"for (let i = 0; (i < 3); (i += 1)) { if ((i == 1)) { continue; }; f(i); }",
+		},
+		{
+			"for (;;) { break }",
+			"for (; ; ) { break; }",
+		},
+		{
+			"while (true) {\n  while (false) { }\n  break\n}\nx",
+			"while (true) { while (false) { } break; }x",
+		},
+		{
+			"for (k, v in h) { f(k, v) }",
+			"for (k, v in h) { f(k, v); }",
+		},
+		{
+			"for (i in 1..=3) { for (j in 0..i) { } }",
+			"for (i in (1 ..= 3)) { for (j in (0 .. i)) { } }",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%s, got=%s", tt.expected, actual)
+		}
+
+		l = lexer.New(actual)
+		p = New(l)
+		reparsed := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if reparsed.String() != actual {
+			t.Errorf("round trip expected=%s, got=%s", actual, reparsed.String())
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	input := `match (x) {
+	0 => "zero",
+	-1.5 => "negative",
+	[a, _] if a > 0 => a
+	{"k": [v]} => v,
+	n => n,
+}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Number of statements expected=%d, got=%d", 1, len(program.Statements))
+	}
+
+	statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("statement is expected=%s, got=%T", "*ast.ExpressionStatement", program.Statements[0])
+	}
+
+	expression, ok := statement.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("expression is expected=%s, got=%T", "*ast.MatchExpression", statement.Expression)
+	}
+	if !testIdentifier(t, expression.Subject, "x") {
+		return
+	}
+
+	tests := []struct {
+		expectedPattern string
+		expectedType    string
+		expectedGuard   string
+		expectedBody    string
+	}{
+		{"0", "*ast.LiteralPattern", "", "\"zero\""},
+		{"-1.5", "*ast.LiteralPattern", "", "\"negative\""},
+		{"[a, _]", "*ast.ArrayPattern", "(a > 0)", "a"},
+		{"{\"k\": [v]}", "*ast.HashPattern", "", "v"},
+		{"n", "*ast.BindingPattern", "", "n"},
+	}
+
+	if len(expression.Arms) != len(tests) {
+		t.Fatalf("Number of arms expected=%d, got=%d", len(tests), len(expression.Arms))
+	}
+
+	for i, tt := range tests {
+		arm := expression.Arms[i]
+		if arm.Pattern.String() != tt.expectedPattern {
+			t.Errorf("arms[%d].Pattern expected=%s, got=%s", i, tt.expectedPattern, arm.Pattern.String())
+		}
+		if actual := fmt.Sprintf("%T", arm.Pattern); actual != tt.expectedType {
+			t.Errorf("arms[%d].Pattern is expected=%s, got=%s", i, tt.expectedType, actual)
+		}
+		if tt.expectedGuard == "" && arm.Guard != nil {
+			t.Errorf("arms[%d].Guard expected=nil, got=%s", i, arm.Guard.String())
+		}
+		if tt.expectedGuard != "" && (arm.Guard == nil || arm.Guard.String() != tt.expectedGuard) {
+			t.Errorf("arms[%d].Guard expected=%s, got=%v", i, tt.expectedGuard, arm.Guard)
+		}
+		if arm.Body.String() != tt.expectedBody {
+			t.Errorf("arms[%d].Body expected=%s, got=%s", i, tt.expectedBody, arm.Body.String())
+		}
+	}
+}
+
+func TestMatchRoundTrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"match (x) { 1 => a, 'c' => b, _ => c }",
+			"match (x) { 1 => a, 'c' => b, _ => c }",
+		},
+		{
+			"match (f(x)) { [] => 0, [h, t] if h == t => 1 + 2, _ => -1 }",
+			"match (f(x)) { [] => 0, [h, t] if (h == t) => (1 + 2), _ => (-1) }",
+		},
+		{
+			"match (p) { {} => true, {\"x\": -2, 1: [_, y]} => y, v => v }",
+			"match (p) { {} => true, {\"x\": -2, 1: [_, y]} => y, v => v }",
+		},
+		{
+			"let r = match (n) {\n  0 => \"none\"\n  _ => \"some\"\n}\nr",
+			"let r = match (n) { 0 => \"none\", _ => \"some\" };r",
+		},
+		{
+			"match (x) { }",
+			"match (x) { }",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%s, got=%s", tt.expected, actual)
+		}
+
+		l = lexer.New(actual)
+		p = New(l)
+		reparsed := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if reparsed.String() != actual {
+			t.Errorf("round trip expected=%s, got=%s", actual, reparsed.String())
+		}
+	}
+}
+
+func TestMatchExhaustivenessWarning(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedWarning bool
+	}{
+		{"match (x) { 1 => 2, _ => 3 }", false},
+		{"match (x) { 1 => 2, n => n }", false},
+		{"match (x) { 1 => 2 }", true},
+		{"match (x) { }", true},
+		{"match (x) { [_] => 2, {} => 3 }", true},
+		{"match (x) { _ if x > 1 => 2 }", true},
+		{"match (x) { n if n > 1 => 2, m => m }", false},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		checkParserErrors(t, p)
+
+		diagnostics := p.Diagnostics()
+		if !tt.expectedWarning {
+			if len(diagnostics) != 0 {
+				t.Errorf("unexpected diagnostics for %q: %v", tt.input, diagnostics)
+			}
+			continue
+		}
+
+		if len(diagnostics) != 1 {
+			t.Fatalf("number of diagnostics for %q expected=%d, got=%d", tt.input, 1, len(diagnostics))
+		}
+
+		d := diagnostics[0]
+		if d.Severity != diagnostic.Warning {
+			t.Errorf("severity expected=%s, got=%s", diagnostic.Warning, d.Severity)
+		}
+		if d.Code != diagnostic.NonExhaustiveMatch {
+			t.Errorf("code expected=%s, got=%s", diagnostic.NonExhaustiveMatch, d.Code)
+		}
+		if d.String() != "match has no wildcard arm at 1:1" {
+			t.Errorf("warning expected=%q, got=%q", "match has no wildcard arm at 1:1", d.String())
+		}
+	}
+
+	// A match that fails to parse is not also reported as non-exhaustive.
+	l := lexer.New("match (x) { 1 => }")
+	p := New(l)
+	p.ParseProgram()
+	if len(p.Diagnostics()) != 1 || p.Diagnostics()[0].Severity != diagnostic.Error {
+		t.Errorf("expected a single error, got=%v", p.Diagnostics())
+	}
+}
+
+func TestInvalidPatterns(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"match (x) { 1 + 2 => y, _ => z }", "next token type expected==>, got=+ at 1:15"},
+		{"match (x) { f(y) => y, _ => z }", "next token type expected==>, got=( at 1:14"},
+		{"match (x) { -a => 1, _ => z }", "expected a pattern, got=- at 1:13"},
+		{"match (x) { {k: 1} => 1, _ => z }", "expected a pattern, got=IDENT at 1:14"},
+		{"match (x) { [1 2] => 1, _ => z }", "next token type expected=], got=INT at 1:16"},
+		{"match (x) { 1 => 2 3 => 4 }", "next token type expected=}, got=INT at 1:20"},
+		{"match x { _ => 1 }", "next token type expected=(, got=IDENT at 1:7"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("number of errors for %q expected=%d, got=%d (%v)", tt.input, 1, len(errors), errors)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("error expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	input := `fn(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Number of statements expected=%d, got=%d",
+			1, len(program.Statements))
+	}
+
+	s, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("s is expected=%s, got=%T", "*ast.ExpressionStatement", program.Statements[0])
+	}
+
+	function, ok := s.Expression.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.FunctionLiteral", s.Expression)
+	}
+
+	if len(function.Parameters) != 2 {
+		t.Fatalf("Number of parameters expected=%d, got=%d", 2, len(function.Parameters))
+	}
+
+	testLiteralExpression(t, function.Parameters[0], "x")
+	testLiteralExpression(t, function.Parameters[1], "y")
+
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("Number of body statements expected=%d, got=%d",
+			1, len(function.Body.Statements))
+	}
+
+	bodyStatement, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("body statement is expected=%s, got=%T",
+			"*ast.ExpressionStatement", function.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStatement.Expression, "x", "+", "y")
+}
+
+func TestFunctionParameterParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+	}{
+		{input: "fn() {};", expectedParams: []string{}},
+		{input: "fn(x) {};", expectedParams: []string{"x"}},
+		{input: "fn(x, y, z) {};", expectedParams: []string{"x", "y", "z"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		s := program.Statements[0].(*ast.ExpressionStatement)
+		function := s.Expression.(*ast.FunctionLiteral)
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("Number of parameters expected=%d, got=%d",
+				len(tt.expectedParams), len(function.Parameters))
+		}
+
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+	}
+}
+
+func TestCallExpressionParsing(t *testing.T) {
+	input := "add(1, 2 * 3, 4 + 5);"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Number of statements expected=%d, got=%d",
+			1, len(program.Statements))
+	}
+
+	s, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("s is expected=%s, got=%T", "*ast.ExpressionStatement", program.Statements[0])
+	}
+
+	expression, ok := s.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.CallExpression", s.Expression)
+	}
+
+	if !testIdentifier(t, expression.Function, "add") {
+		return
+	}
+
+	if len(expression.Arguments) != 3 {
+		t.Fatalf("Number of arguments expected=%d, got=%d", 3, len(expression.Arguments))
+	}
+
+	testLiteralExpression(t, expression.Arguments[0], 1)
+	testInfixExpression(t, expression.Arguments[1], 2, "*", 3)
+	testInfixExpression(t, expression.Arguments[2], 4, "+", 5)
+}
+
+func TestCallExpressionArgumentParsing(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedIdent string
+		expectedArgs  []string
+	}{
+		{input: "add();", expectedIdent: "add", expectedArgs: []string{}},
+		{input: "add(1);", expectedIdent: "add", expectedArgs: []string{"1"}},
+		{input: "add(1, 2 * 3, 4 + 5);", expectedIdent: "add", expectedArgs: []string{"1", "(2 * 3)", "(4 + 5)"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		s := program.Statements[0].(*ast.ExpressionStatement)
+		expression, ok := s.Expression.(*ast.CallExpression)
+		if !ok {
+			t.Fatalf("s.Expression is expected=%s, got=%T", "*ast.CallExpression", s.Expression)
+		}
+
+		if !testIdentifier(t, expression.Function, tt.expectedIdent) {
+			return
+		}
+
+		if len(expression.Arguments) != len(tt.expectedArgs) {
+			t.Fatalf("Number of arguments expected=%d, got=%d",
+				len(tt.expectedArgs), len(expression.Arguments))
+		}
+
+		for i, arg := range tt.expectedArgs {
+			if expression.Arguments[i].String() != arg {
+				t.Errorf("argument %d expected=%q, got=%q", i, arg, expression.Arguments[i].String())
+			}
+		}
+	}
+}
+
+func TestNodeSpans(t *testing.T) {
+	input := "let add = fn(a, b) {\n  a + b\n};\nadd(1, [2][0]) * -x"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	let := program.Statements[0].(*ast.LetStatement)
+	function := let.Value.(*ast.FunctionLiteral)
+	body := function.Body.Statements[0].(*ast.ExpressionStatement)
+	product := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.InfixExpression)
+	call := product.Left.(*ast.CallExpression)
+	index := call.Arguments[1].(*ast.IndexExpression)
+
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{let, "let add = fn(a, b) {\n  a + b\n}"},
+		{function, "fn(a, b) {\n  a + b\n}"},
+		{function.Body, "{\n  a + b\n}"},
+		{body, "a + b"},
+		{product, "add(1, [2][0]) * -x"},
+		{call, "add(1, [2][0])"},
+		{index, "[2][0]"},
+		{index.Left, "[2]"},
+		{product.Right, "-x"},
+		{program, input},
+	}
+
+	for _, tt := range tests {
+		actual := input[tt.node.Pos().Offset:tt.node.End().Offset]
+		if actual != tt.expected {
+			t.Errorf("span of %T expected=%q, got=%q", tt.node, tt.expected, actual)
+		}
+	}
+
+	if pos := body.Pos(); pos.Line != 2 || pos.Column != 3 {
+		t.Errorf("body position expected=2:3, got=%s", pos)
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let = 5;", "next token type expected=IDENT, got== at 1:5"},
+		{"let x = 1;\nlet y 2;", "next token type expected==, got=INT at 2:7"},
+		{"1 +\n  ;", "No prefix parse function for ; found at 2:3"},
+		{"fn(x) {", "expected }, got=EOF at 1:8"},
+		{"while (true) {\n  x += 1", "expected }, got=EOF at 2:9"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected errors for %q", tt.input)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("error expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	input := "let x 5;\n\"open"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	tests := []struct {
+		code   diagnostic.Code
+		pos    string
+		endCol int
+	}{
+		{diagnostic.UnexpectedToken, "1:7", 8},
+		{diagnostic.UnterminatedString, "2:1", 6},
+	}
+
+	diagnostics := p.Diagnostics()
+	if len(diagnostics) != len(tests) {
+		t.Fatalf("Number of diagnostics expected=%d, got=%d (%v)", len(tests), len(diagnostics), diagnostics)
+	}
+
+	for i, tt := range tests {
+		d := diagnostics[i]
+		if d.Severity != diagnostic.Error {
+			t.Errorf("diagnostics[%d] severity expected=%s, got=%s", i, diagnostic.Error, d.Severity)
+		}
+		if d.Code != tt.code {
+			t.Errorf("diagnostics[%d] code expected=%s, got=%s", i, tt.code, d.Code)
+		}
+		if d.Pos.String() != tt.pos {
+			t.Errorf("diagnostics[%d] pos expected=%s, got=%s", i, tt.pos, d.Pos)
+		}
+		if d.End.Column != tt.endCol {
+			t.Errorf("diagnostics[%d] end column expected=%d, got=%d", i, tt.endCol, d.End.Column)
+		}
+	}
+}
+
+func TestDiagnosticHintAtEOF(t *testing.T) {
+	l := lexer.New("add(1, 2")
+	p := New(l)
+	p.ParseProgram()
+
+	diagnostics := p.Diagnostics()
+	if len(diagnostics) == 0 {
+		t.Fatalf("expected diagnostics")
+	}
+
+	d := diagnostics[0]
+	if d.Code != diagnostic.UnexpectedToken {
+		t.Errorf("code expected=%s, got=%s", diagnostic.UnexpectedToken, d.Code)
+	}
+	if len(d.Hints) != 1 || d.Hints[0] != `the input ended while looking for ")"` {
+		t.Errorf("unexpected hints: %v", d.Hints)
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements []string
+	}{
+		{
+			"let x 5; let y = 10;",
+			[]string{"next token type expected==, got=INT at 1:7"},
+			[]string{"let y = 10;"},
+		},
+		{
+			"let = 1; let = 2; let z = 3;",
+			[]string{
+				"next token type expected=IDENT, got== at 1:5",
+				"next token type expected=IDENT, got== at 1:14",
+			},
+			[]string{"let z = 3;"},
+		},
+		{
+			"add(1, ; let y = 2;",
+			[]string{"No prefix parse function for ; found at 1:8"},
+			[]string{"let y = 2;"},
+		},
+		{
+			"let f = fn() { let = 1; x }; f",
+			[]string{"next token type expected=IDENT, got== at 1:20"},
+			[]string{"let f = fn() { x; };", "f"},
+		},
+		{
+			"if (x { y } let z = 1;",
+			[]string{"next token type expected=), got={ at 1:7"},
+			[]string{"let z = 1;"},
+		},
+		{
+			"return 1 +",
+			[]string{"No prefix parse function for EOF found at 1:11"},
+			[]string{},
+		},
+		{
+			"let x = 5",
+			[]string{},
+			[]string{"let x = 5;"},
+		},
+		{
+			"return x",
+			[]string{},
+			[]string{"return x;"},
+		},
+		{
+			"let f = fn(x) { match (x) { 1 => , _ => 2 } }; 3",
+			[]string{"No prefix parse function for , found at 1:34"},
+			[]string{"let f = fn(x) { };", "3"},
+		},
+		{
+			`match (1) { 1 => "a" _ => "b" }; 3`,
+			[]string{"next token type expected=}, got=_ at 1:22"},
+			[]string{"3"},
+		},
+		{
+			"let h = {1 2, 3: 4}; h",
+			[]string{"next token type expected=:, got=INT at 1:12"},
+			[]string{"h"},
+		},
+		{
+			"let x = 1 @ 2\nlet y = 3",
+			[]string{"illegal character '@' at 1:11"},
+			[]string{"let y = 3;"},
+		},
+		{
+			"let x = @ + 1; y",
+			[]string{"illegal character '@' at 1:9"},
+			[]string{"y"},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("Number of errors for %q expected=%d, got=%d (%q)",
+				tt.input, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+		for i, msg := range tt.expectedErrors {
+			if errors[i] != msg {
+				t.Errorf("error %d for %q expected=%q, got=%q", i, tt.input, msg, errors[i])
+			}
+		}
+
+		if len(program.Statements) != len(tt.expectedStatements) {
+			t.Errorf("Number of statements for %q expected=%d, got=%d (%s)",
+				tt.input, len(tt.expectedStatements), len(program.Statements), program.String())
+			continue
+		}
+		for i, statement := range tt.expectedStatements {
+			if program.Statements[i].String() != statement {
+				t.Errorf("statement %d for %q expected=%s, got=%s",
+					i, tt.input, statement, program.Statements[i].String())
+			}
+		}
+	}
+}
+
+func TestOptionalSemicolons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			"let x = 5\nlet y = x\nreturn x + y",
+			[]string{"let x = 5;", "let y = x;", "return (x + y);"},
+		},
+		{
+			"let x = 5;\r\nlet y = 10;\r\n",
+			[]string{"let x = 5;", "let y = 10;"},
+		},
+		{
+			"let a = 1\n-a",
+			[]string{"let a = 1;", "(-a)"},
+		},
+		{
+			"let a = 1 +\n  2",
+			[]string{"let a = (1 + 2);"},
+		},
+		{
+			"let f = fn(x) {\n  let y = x * 2\n  return y\n}\nf(2)",
+			[]string{"let f = fn(x) { let y = (x * 2); return y; };", "f(2)"},
+		},
+		{
+			"let f = fn(x) { return x }",
+			[]string{"let f = fn(x) { return x; };"},
+		},
+		{
+			"if (x) { 1 } else { 2 }\nfoo",
+			[]string{"if (x) { 1; } else { 2; }", "foo"},
+		},
+		{
+			"if (x) { 1 }\nelse { 2 }",
+			[]string{"if (x) { 1; } else { 2; }"},
+		},
+		{
+			"f\n(1)",
+			[]string{"f", "1"},
+		},
+		{
+			"let xs = [\n  1,\n  2\n]\nxs",
+			[]string{"let xs = [1, 2];", "xs"},
+		},
+		{
+			"{ 1 }; 2",
+			[]string{"{ 1; }", "2"},
+		},
+		{
+			"{ let a = 1 }; 2",
+			[]string{"{ let a = 1; }", "2"},
+		},
+		{
+			"{ { a } }; { b }\n3",
+			[]string{"{ { a; } }", "{ b; }", "3"},
+		},
+		{
+			"while (false) { }; 2",
+			[]string{"while (false) { }", "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != len(tt.expected) {
+			t.Fatalf("Number of statements for %q expected=%d, got=%d (%s)",
+				tt.input, len(tt.expected), len(program.Statements), program.String())
+		}
+		for i, expected := range tt.expected {
+			if program.Statements[i].String() != expected {
+				t.Errorf("statement %d for %q expected=%s, got=%s",
+					i, tt.input, expected, program.Statements[i].String())
+			}
+		}
+	}
+}
+
+func TestCommentsAreTrivia(t *testing.T) {
+	input := `let x = 1 // one
+/* the
+   answer */ let y = x /* inline */ + 2
+y`
+	expected := "let x = 1;let y = (x + 2);y"
+
+	for _, l := range []*lexer.Lexer{lexer.New(input), lexer.New(input, lexer.KeepComments())} {
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != expected {
+			t.Errorf("expected=%s, got=%s", expected, program.String())
+		}
+	}
+}
+
+func TestMissingStatementTerminator(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let x = 1 let y = 2", "expected ; or newline after statement, got=LET at 1:11"},
+		{"return x y", "expected ; or newline after statement, got=IDENT at 1:10"},
+		{"a b", "expected ; or newline after statement, got=IDENT at 1:3"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("Number of errors for %q expected=%d, got=%d (%q)", tt.input, 1, len(errors), errors)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("error expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestParsingTerminates(t *testing.T) {
+	inputs := []string{
+		"let",
+		"let x",
+		"let x =",
+		"return",
+		"fn(",
+		"fn(x, ",
+		"fn(x) {",
+		"if (",
+		"if (x) { 1 } else",
+		"[1, 2",
+		"{1: ",
+		"{",
+		"}}}}",
+		"a[",
+		"let x = { let y = 1;",
+		"if (true) { 1",
+		"{ let a = 1; a",
+		"while (true) {",
+		"match (1) { _ => fn() {",
+		";;;;",
+		"@ # $",
+	}
+
+	for _, input := range inputs {
+		p := New(lexer.New(input))
+		done := make(chan struct{})
+		go func() {
+			p.ParseProgram()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("parsing %q did not terminate", input)
+		}
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("parsing %q reported no errors", input)
+		}
+	}
+}
+
+func TestTooManyErrors(t *testing.T) {
+	input := strings.Repeat("let = 1;\n", maxErrors+5)
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	diagnostics := p.Diagnostics()
+	if len(diagnostics) != maxErrors+1 {
+		t.Fatalf("Number of diagnostics expected=%d, got=%d", maxErrors+1, len(diagnostics))
+	}
+
+	last := diagnostics[len(diagnostics)-1]
+	if last.Code != diagnostic.TooManyErrors {
+		t.Errorf("last diagnostic code expected=%s, got=%s", diagnostic.TooManyErrors, last.Code)
+	}
+	if last.Pos.Line != maxErrors+1 {
+		t.Errorf("last diagnostic line expected=%d, got=%d", maxErrors+1, last.Pos.Line)
 	}
 }
 
@@ -3,8 +3,12 @@ package repl
 import (
 	"bufio"
 	"fmt"
+	"github.com/arjunmayilvaganan/nibbl/diagnostic"
+	"github.com/arjunmayilvaganan/nibbl/evaluator"
 	"github.com/arjunmayilvaganan/nibbl/lexer"
-	"github.com/arjunmayilvaganan/nibbl/token"
+	"github.com/arjunmayilvaganan/nibbl/object"
+	"github.com/arjunmayilvaganan/nibbl/parser"
+	"github.com/arjunmayilvaganan/nibbl/resolver"
 	"io"
 )
 
@@ -12,8 +16,11 @@ const PROMPT = ">>"
 
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
+	env := object.NewEnvironment()
+	r := resolver.New()
+
 	for {
-		fmt.Printf(PROMPT)
+		fmt.Fprintf(out, PROMPT)
 
 		scanned := scanner.Scan()
 		if !scanned {
@@ -22,9 +29,32 @@ func Start(in io.Reader, out io.Writer) {
 
 		line := scanner.Text()
 		l := lexer.New(line)
+		p := parser.New(l)
+
+		program := p.ParseProgram()
+		printDiagnostics(out, line, p.Diagnostics())
+		if len(p.Errors()) != 0 {
+			continue
+		}
+
+		if diagnostics := r.Resolve(program); len(diagnostics) != 0 {
+			printDiagnostics(out, line, diagnostics)
+			continue
+		}
 
-		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
-			fmt.Printf("%+v\n", tok)
+		evaluated := evaluator.Eval(program, env)
+		if _, failed := evaluated.(*object.Error); !failed {
+			r.Commit()
 		}
+		if evaluated != nil {
+			io.WriteString(out, evaluated.Inspect())
+			io.WriteString(out, "\n")
+		}
+	}
+}
+
+func printDiagnostics(out io.Writer, source string, diagnostics []diagnostic.Diagnostic) {
+	for _, d := range diagnostics {
+		io.WriteString(out, d.Render(source))
 	}
 }
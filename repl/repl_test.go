@@ -0,0 +1,30 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFailedLinesDoNotDeclare(t *testing.T) {
+	input := "undefined(); const Z = 1\nlet Z = 5\nZ\n"
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expected := PROMPT + "ERROR: identifier not found: undefined\n" + PROMPT + PROMPT + "5\n" + PROMPT
+	if out.String() != expected {
+		t.Errorf("output expected=%q, got=%q", expected, out.String())
+	}
+}
+
+func TestConstantsPersistAcrossLines(t *testing.T) {
+	input := "const PI = 3\nPI = 4\n"
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	if !strings.Contains(out.String(), "error[R001]: cannot assign to constant PI") {
+		t.Errorf("expected assignment to constant to be rejected, got=%q", out.String())
+	}
+}
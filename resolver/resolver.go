@@ -0,0 +1,275 @@
+// Package resolver checks a parsed program for binding errors that can be
+// found before it runs, such as assigning to a constant.
+package resolver
+
+import (
+	"fmt"
+	"github.com/arjunmayilvaganan/nibbl/ast"
+	"github.com/arjunmayilvaganan/nibbl/diagnostic"
+	"github.com/arjunmayilvaganan/nibbl/token"
+)
+
+// binding records how a name was declared.
+type binding struct {
+	constant bool
+	pos      token.Position
+}
+
+// scope mirrors an object.Environment: the program has one, every
+// function body gets its own, and so do loop headers, loop bodies and
+// match arms.
+type scope struct {
+	bindings map[string]binding
+	outer    *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{bindings: make(map[string]binding), outer: outer}
+}
+
+func (s *scope) lookup(name string) (binding, bool) {
+	b, ok := s.bindings[name]
+	if !ok && s.outer != nil {
+		b, ok = s.outer.lookup(name)
+	}
+	return b, ok
+}
+
+type Resolver struct {
+	global      *scope // top-level declarations of committed programs
+	pending     *scope // global extended by the program last resolved
+	scope       *scope
+	loops       int // number of loops enclosing the current node within its function
+	diagnostics []diagnostic.Diagnostic
+}
+
+// New returns a Resolver whose top-level scope persists across calls to
+// Resolve, so that programs evaluated in one environment, like REPL
+// lines, are checked against each other. The declarations of a program
+// are only kept once it is committed.
+func New() *Resolver {
+	global := newScope(nil)
+	return &Resolver{global: global, scope: global}
+}
+
+// Resolve checks program against the committed top-level declarations
+// and returns the diagnostics found in it.
+func (r *Resolver) Resolve(program *ast.Program) []diagnostic.Diagnostic {
+	r.pending = newScope(nil)
+	for name, b := range r.global.bindings {
+		r.pending.bindings[name] = b
+	}
+
+	r.diagnostics = []diagnostic.Diagnostic{}
+	r.scope = r.pending
+	r.loops = 0
+	r.resolve(program)
+
+	// A program with errors is not expected to run, so it cannot be
+	// committed.
+	if len(r.diagnostics) != 0 {
+		r.pending = nil
+	}
+	return r.diagnostics
+}
+
+// Commit keeps the top-level declarations of the program last passed to
+// Resolve. It should be called once that program has run successfully;
+// until then later programs are checked as if it had never been seen.
+func (r *Resolver) Commit() {
+	if r.pending != nil {
+		r.global = r.pending
+		r.pending = nil
+	}
+}
+
+func (r *Resolver) error(code diagnostic.Code, node ast.Node, format string, a ...interface{}) *diagnostic.Diagnostic {
+	r.diagnostics = append(r.diagnostics, diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Pos:      node.Pos(),
+		End:      node.End(),
+	})
+	return &r.diagnostics[len(r.diagnostics)-1]
+}
+
+func (r *Resolver) resolve(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, s := range node.Statements {
+			r.resolve(s)
+		}
+
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			r.resolve(s)
+		}
+
+	case *ast.LetStatement:
+		// A function body runs only once it is called, by which point its
+		// own name is bound, so it sees the binding it is assigned to.
+		if _, ok := node.Value.(*ast.FunctionLiteral); ok {
+			r.declare(node)
+			r.resolveExpression(node.Value)
+			break
+		}
+		r.resolveExpression(node.Value)
+		r.declare(node)
+
+	case *ast.ReturnStatement:
+		r.resolveExpression(node.ReturnValue)
+
+	case *ast.WhileStatement:
+		r.resolveExpression(node.Condition)
+		r.resolveLoopBody(node.Body)
+
+	case *ast.ForStatement:
+		r.scope = newScope(r.scope)
+		if node.Init != nil {
+			r.resolve(node.Init)
+		}
+		r.resolveExpression(node.Condition)
+		r.resolveLoopBody(node.Body)
+		r.resolveExpression(node.Post)
+		r.scope = r.scope.outer
+
+	case *ast.ForInStatement:
+		r.resolveExpression(node.Iterable)
+		r.scope = newScope(r.scope)
+		if node.Key != nil {
+			r.scope.bindings[node.Key.Value] = binding{pos: node.Key.Pos()}
+		}
+		r.scope.bindings[node.Value.Value] = binding{pos: node.Value.Pos()}
+		r.resolveLoopBody(node.Body)
+		r.scope = r.scope.outer
+
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		if r.loops == 0 {
+			r.error(diagnostic.LoopControlOutsideLoop, node, "%s outside of a loop", node.TokenLiteral())
+		}
+
+	case *ast.ExpressionStatement:
+		r.resolveExpression(node.Expression)
+	}
+}
+
+func (r *Resolver) resolveExpression(node ast.Expression) {
+	switch node := node.(type) {
+	case *ast.PrefixExpression:
+		r.resolveExpression(node.Right)
+
+	case *ast.InfixExpression:
+		r.resolveExpression(node.Left)
+		r.resolveExpression(node.Right)
+
+	case *ast.AssignExpression:
+		r.resolveExpression(node.Target)
+		r.resolveExpression(node.Value)
+		r.checkAssignment(node)
+
+	case *ast.IfExpression:
+		r.resolveExpression(node.Condition)
+		r.resolve(node.Consequence)
+		if node.Alternative != nil {
+			r.resolve(node.Alternative)
+		}
+
+	case *ast.FunctionLiteral:
+		// A loop around the function does not make break valid inside it.
+		loops := r.loops
+		r.loops = 0
+		r.scope = newScope(r.scope)
+		for _, param := range node.Parameters {
+			r.scope.bindings[param.Value] = binding{pos: param.Pos()}
+		}
+		r.resolve(node.Body)
+		r.scope = r.scope.outer
+		r.loops = loops
+
+	case *ast.MatchExpression:
+		r.resolveExpression(node.Subject)
+		for _, arm := range node.Arms {
+			r.scope = newScope(r.scope)
+			r.declarePattern(arm.Pattern)
+			r.resolveExpression(arm.Guard)
+			r.resolveExpression(arm.Body)
+			r.scope = r.scope.outer
+		}
+
+	case *ast.CallExpression:
+		r.resolveExpression(node.Function)
+		for _, arg := range node.Arguments {
+			r.resolveExpression(arg)
+		}
+
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			r.resolveExpression(el)
+		}
+
+	case *ast.IndexExpression:
+		r.resolveExpression(node.Left)
+		r.resolveExpression(node.Index)
+
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			r.resolveExpression(pair.Key)
+			r.resolveExpression(pair.Value)
+		}
+	}
+}
+
+func (r *Resolver) resolveLoopBody(body *ast.BlockStatement) {
+	r.loops++
+	r.scope = newScope(r.scope)
+	r.resolve(body)
+	r.scope = r.scope.outer
+	r.loops--
+}
+
+// declare adds the binding made by a let or const statement to the
+// current scope. Neither may replace a constant declared in the same scope.
+func (r *Resolver) declare(node *ast.LetStatement) {
+	if node.Name == nil {
+		return
+	}
+
+	name := node.Name.Value
+	if existing, ok := r.scope.bindings[name]; ok && existing.constant {
+		d := r.error(diagnostic.ConstantRedeclaration, node.Name, "cannot redeclare constant %s", name)
+		d.Hints = append(d.Hints, fmt.Sprintf("%s was declared constant at %s", name, existing.pos))
+		return
+	}
+
+	r.scope.bindings[name] = binding{constant: node.IsConst(), pos: node.Name.Pos()}
+}
+
+// declarePattern adds the names bound by a match pattern to the current
+// scope.
+func (r *Resolver) declarePattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		r.scope.bindings[pattern.Name.Value] = binding{pos: pattern.Pos()}
+	case *ast.ArrayPattern:
+		for _, el := range pattern.Elements {
+			r.declarePattern(el)
+		}
+	case *ast.HashPattern:
+		for _, pair := range pattern.Pairs {
+			r.declarePattern(pair.Value)
+		}
+	}
+}
+
+func (r *Resolver) checkAssignment(node *ast.AssignExpression) {
+	target, ok := node.Target.(*ast.Identifier)
+	if !ok {
+		return
+	}
+
+	if b, ok := r.scope.lookup(target.Value); ok && b.constant {
+		d := r.error(diagnostic.ConstantAssignment, target, "cannot assign to constant %s", target.Value)
+		d.Hints = append(d.Hints, fmt.Sprintf("%s was declared constant at %s", target.Value, b.pos))
+	}
+}
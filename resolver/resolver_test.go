@@ -0,0 +1,169 @@
+package resolver
+
+import (
+	"github.com/arjunmayilvaganan/nibbl/ast"
+	"github.com/arjunmayilvaganan/nibbl/diagnostic"
+	"github.com/arjunmayilvaganan/nibbl/lexer"
+	"github.com/arjunmayilvaganan/nibbl/parser"
+	"testing"
+)
+
+func TestValidPrograms(t *testing.T) {
+	tests := []string{
+		"const PI = 3; let x = PI * 2; x = 4;",
+		"let x = 1; let x = 2; x = 3;",
+		"let x = 1; const x = 2;",
+		"const PI = 3; let f = fn() { let PI = 4; PI = 5; }",
+		"const PI = 3; let f = fn(PI) { PI += 1 }",
+		"const PI = 3; let f = fn() { const PI = 4; PI }",
+		"const arr = [1, 2]; arr[0] = 3;",
+		"const h = {}; h[\"k\"] = 1;",
+		"y = 1;",
+		"while (true) { break }",
+		"for (;;) { if (x) { continue } else { break } }",
+		"while (true) { const c = 1; let f = fn() { while (c) { break } }; break }",
+		"for (const i = 0; i < 3; ) { const j = i; break }",
+		"const x = 1; for (x in [1, 2]) { x = 3 }",
+		"const k = 1; for (k, v in {}) { k += v; break }",
+		"for (i in 0..3) { const c = i; continue }",
+		"const x = 1; match (2) { x => x = 3, [x, {\"k\": x}] => x += 1, _ => 0 }",
+		"while (true) { match (1) { 1 => if (true) { break }, _ => 0 } }",
+	}
+
+	for _, input := range tests {
+		diagnostics := New().Resolve(parse(t, input))
+		if len(diagnostics) != 0 {
+			t.Errorf("unexpected diagnostics for %q: %v", input, diagnostics)
+		}
+	}
+}
+
+func TestConstantErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedCode  diagnostic.Code
+		expectedError string
+		expectedHint  string
+	}{
+		{"const PI = 3; PI = 4;", diagnostic.ConstantAssignment,
+			"cannot assign to constant PI at 1:15", "PI was declared constant at 1:7"},
+		{"const PI = 3;\nPI += 1;", diagnostic.ConstantAssignment,
+			"cannot assign to constant PI at 2:1", "PI was declared constant at 1:7"},
+		{"const PI = 3; let PI = 4;", diagnostic.ConstantRedeclaration,
+			"cannot redeclare constant PI at 1:19", "PI was declared constant at 1:7"},
+		{"const PI = 3; const PI = 4;", diagnostic.ConstantRedeclaration,
+			"cannot redeclare constant PI at 1:21", "PI was declared constant at 1:7"},
+		{"const PI = 3; let f = fn() { PI = 4 }", diagnostic.ConstantAssignment,
+			"cannot assign to constant PI at 1:30", "PI was declared constant at 1:7"},
+		{"let f = fn() { const n = 1; if (true) { n = 2 } }", diagnostic.ConstantAssignment,
+			"cannot assign to constant n at 1:41", "n was declared constant at 1:22"},
+		{"const a = 1; let b = [a = 2];", diagnostic.ConstantAssignment,
+			"cannot assign to constant a at 1:23", "a was declared constant at 1:7"},
+		{"for (const i = 0; i < 3; i += 1) { }", diagnostic.ConstantAssignment,
+			"cannot assign to constant i at 1:26", "i was declared constant at 1:12"},
+		{"const n = 3; for (i in 0..n) { n = i }", diagnostic.ConstantAssignment,
+			"cannot assign to constant n at 1:32", "n was declared constant at 1:7"},
+		{"const n = 3; match (1) { m if (n = m) => 1, _ => 2 }", diagnostic.ConstantAssignment,
+			"cannot assign to constant n at 1:32", "n was declared constant at 1:7"},
+		{"const f = fn() { f = 1 }; f()", diagnostic.ConstantAssignment,
+			"cannot assign to constant f at 1:18", "f was declared constant at 1:7"},
+	}
+
+	for _, tt := range tests {
+		diagnostics := New().Resolve(parse(t, tt.input))
+		if len(diagnostics) != 1 {
+			t.Fatalf("number of diagnostics for %q expected=%d, got=%d (%v)", tt.input, 1, len(diagnostics), diagnostics)
+		}
+
+		d := diagnostics[0]
+		if d.Code != tt.expectedCode {
+			t.Errorf("code expected=%s, got=%s", tt.expectedCode, d.Code)
+		}
+		if d.String() != tt.expectedError {
+			t.Errorf("error expected=%q, got=%q", tt.expectedError, d.String())
+		}
+		if len(d.Hints) != 1 || d.Hints[0] != tt.expectedHint {
+			t.Errorf("hints expected=%q, got=%q", tt.expectedHint, d.Hints)
+		}
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"break;", "break outside of a loop at 1:1"},
+		{"if (true) { continue }", "continue outside of a loop at 1:13"},
+		{"while (true) { let f = fn() { break } }", "break outside of a loop at 1:31"},
+		{"for (;;) { }\ncontinue", "continue outside of a loop at 2:1"},
+		{"for (x in xs) { let f = fn() { continue } }", "continue outside of a loop at 1:32"},
+	}
+
+	for _, tt := range tests {
+		diagnostics := New().Resolve(parse(t, tt.input))
+		if len(diagnostics) != 1 {
+			t.Fatalf("number of diagnostics for %q expected=%d, got=%d (%v)", tt.input, 1, len(diagnostics), diagnostics)
+		}
+		if diagnostics[0].Code != diagnostic.LoopControlOutsideLoop {
+			t.Errorf("code expected=%s, got=%s", diagnostic.LoopControlOutsideLoop, diagnostics[0].Code)
+		}
+		if diagnostics[0].String() != tt.expectedError {
+			t.Errorf("error expected=%q, got=%q", tt.expectedError, diagnostics[0].String())
+		}
+	}
+}
+
+func TestScopePersistsAcrossPrograms(t *testing.T) {
+	r := New()
+
+	if diagnostics := r.Resolve(parse(t, "const PI = 3;")); len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+	r.Commit()
+	if diagnostics := r.Resolve(parse(t, "PI = 4;")); len(diagnostics) != 1 {
+		t.Errorf("expected assignment in a later program to be rejected, got=%v", diagnostics)
+	}
+
+	// Declarations of a rejected program are discarded, even if committed.
+	if diagnostics := r.Resolve(parse(t, "const E = 2; PI = 4;")); len(diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic, got=%v", diagnostics)
+	}
+	r.Commit()
+	if diagnostics := r.Resolve(parse(t, "let E = 1;")); len(diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", diagnostics)
+	}
+}
+
+func TestUncommittedDeclarationsAreDiscarded(t *testing.T) {
+	r := New()
+
+	// The program resolves but fails at run time, so it is not committed
+	// and Z is never bound.
+	if diagnostics := r.Resolve(parse(t, "undefined(); const Z = 1")); len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+	if diagnostics := r.Resolve(parse(t, "let Z = 5")); len(diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", diagnostics)
+	}
+	r.Commit()
+
+	if diagnostics := r.Resolve(parse(t, "const Z = 6")); len(diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", diagnostics)
+	}
+	r.Commit()
+	if diagnostics := r.Resolve(parse(t, "Z = 7")); len(diagnostics) != 1 {
+		t.Errorf("expected assignment to committed constant to be rejected, got=%v", diagnostics)
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if errors := p.Errors(); len(errors) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, errors)
+	}
+	return program
+}